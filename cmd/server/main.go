@@ -1,133 +1,151 @@
 package main
 
 import (
+	"context"
+	"log/slog"
 	"os"
-	"regexp"
-	"time"
+	"os/signal"
+	"syscall"
 
 	"github.com/caarlos0/env/v11"
-	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/log"
-	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/fiber/v2/middleware/logger"
-	"github.com/gofiber/fiber/v2/middleware/recover"
 	_ "github.com/joho/godotenv/autoload"
 
 	"github.com/dbytex91/streamx/internal/addon"
-	"github.com/dbytex91/streamx/internal/static"
+	"github.com/dbytex91/streamx/internal/downloader"
+	"github.com/dbytex91/streamx/internal/logging"
+	"github.com/dbytex91/streamx/internal/seal"
+	"github.com/dbytex91/streamx/internal/store"
+	"github.com/dbytex91/streamx/internal/tracing"
 )
 
 type config struct {
-	ProwlarrURL    string `env:"PROWLARR_URL"`
-	ProwlarrAPIKey string `env:"PROWLARR_API_KEY"`
-	RealDebridKey  string `env:"REAL_DEBRID_API_KEY"`
+	ProwlarrURL        string   `env:"PROWLARR_URL"`
+	ProwlarrAPIKey     string   `env:"PROWLARR_API_KEY"`
+	RealDebridKey      string   `env:"REAL_DEBRID_API_KEY"`
+	TMDBAPIKey         string   `env:"TMDB_API_KEY"`
+	PublicTrackers     []string `env:"PUBLIC_TRACKERS"`
+	DownloaderType     string   `env:"DOWNLOADER_TYPE"`
+	DownloaderURL      string   `env:"DOWNLOADER_URL"`
+	DownloaderUsername string   `env:"DOWNLOADER_USERNAME"`
+	DownloaderPassword string   `env:"DOWNLOADER_PASSWORD"`
+	DownloaderCategory string   `env:"DOWNLOADER_CATEGORY"`
+	DownloaderSavePath string   `env:"DOWNLOADER_SAVE_PATH"`
+	DownloaderSecret   string   `env:"DOWNLOADER_SECRET"`
+	DownloaderKeys     []string `env:"DOWNLOADER_ACCESS_KEYS"`
+	StatusVideosURL    string   `env:"STATUS_VIDEOS_URL"`
+	DatabasePath       string   `env:"DATABASE_PATH" envDefault:"data/streamx.db"`
+	UserDataKeys       []string `env:"USERDATA_KEYS"`
+	AllowUnsealed      bool     `env:"ALLOW_UNSEALED_USERDATA"`
+	AllowPrivateURLs   bool     `env:"ALLOW_PRIVATE_PROWLARR_URLS"`
+	LogLevel           string   `env:"LOG_LEVEL" envDefault:"info"`
+	LogFormat          string   `env:"LOG_FORMAT" envDefault:"text"`
+	TracingEndpoint    string   `env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	TracingSampleRatio float64  `env:"TRACING_SAMPLE_RATIO" envDefault:"1"`
+	Server             serverConfig
 }
 
-var (
-	maskedPathPattern = regexp.MustCompile(`^/([\w%]+)/(?:configure|stream|download|manifest)`)
-	version           = "2.0.0"
-)
+var version = "2.0.0"
 
 func main() {
 	cfg := config{}
 	_ = env.Parse(&cfg)
 
-	app := fiber.New()
-	app.Use(cors.New())
-	app.Use(recover.New(recover.Config{
-		EnableStackTrace: true,
-	}))
-
-	app.Use(logger.New(logger.Config{
-		CustomTags: map[string]logger.LogFunc{
-			"maskedPath": func(output logger.Buffer, c *fiber.Ctx, data *logger.Data, extraParam string) (int, error) {
-				urlPath := c.Path()
-				loc := maskedPathPattern.FindStringSubmatchIndex(urlPath)
-				if len(loc) > 3 {
-					return output.WriteString(urlPath[:loc[2]] + "***" + urlPath[loc[3]:])
-				} else {
-					return output.WriteString(urlPath)
-				}
-			},
-		},
-		Format:        "${time} | ${status} | ${latency} | ${ip} | ${method} | ${maskedPath} | ${error}\n",
-		TimeFormat:    "15:04:05",
-		TimeZone:      "Local",
-		TimeInterval:  500 * time.Millisecond,
-		Output:        os.Stdout,
-		DisableColors: false,
-	}))
+	logging.Setup(logging.Config{
+		Level:  cfg.LogLevel,
+		Format: cfg.LogFormat,
+	})
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Endpoint:    cfg.TracingEndpoint,
+		SampleRatio: cfg.TracingSampleRatio,
+		Version:     version,
+	})
+	if err != nil {
+		fatal("Couldn't set up tracing", "error", err)
+	}
+	defer shutdownTracing(context.Background())
 
 	opts := []addon.Option{
 		addon.WithID("com.streamx.addon"),
 		addon.WithName("StreamX"),
 		addon.WithVersion(version),
 	}
-	
+
 	// Only add Prowlarr client if both URL and API key are provided
 	if cfg.ProwlarrURL != "" && cfg.ProwlarrAPIKey != "" {
 		opts = append(opts, addon.WithProwlarr(cfg.ProwlarrURL, cfg.ProwlarrAPIKey))
 	}
-	
+
 	// Only add Real Debrid client if API key is provided
 	if cfg.RealDebridKey != "" {
 		opts = append(opts, addon.WithRealDebrid(cfg.RealDebridKey))
 	}
-	
+
+	// TMDB adds alternative titles and episode runtimes, Cinemeta is used otherwise
+	if cfg.TMDBAPIKey != "" {
+		opts = append(opts, addon.WithTMDB(cfg.TMDBAPIKey))
+	}
+
+	if len(cfg.PublicTrackers) > 0 {
+		opts = append(opts, addon.WithPublicTrackers(cfg.PublicTrackers))
+	}
+
+	// Lets users the operator gave an access key send results to a download
+	// client next to the server
+	if cfg.DownloaderURL != "" && len(cfg.DownloaderKeys) == 0 {
+		slog.Warn("Download to server is disabled, DOWNLOADER_ACCESS_KEYS is empty")
+	} else if cfg.DownloaderURL != "" {
+		client, err := downloader.New(downloader.Config{
+			Type:     cfg.DownloaderType,
+			URL:      cfg.DownloaderURL,
+			Username: cfg.DownloaderUsername,
+			Password: cfg.DownloaderPassword,
+			Category: cfg.DownloaderCategory,
+			SavePath: cfg.DownloaderSavePath,
+		})
+		if err != nil {
+			fatal("Invalid download client configuration", "error", err)
+		}
+		opts = append(opts, addon.WithDownloader(client, cfg.DownloaderSecret, cfg.DownloaderKeys))
+	}
+
+	if cfg.StatusVideosURL != "" {
+		opts = append(opts, addon.WithStatusVideos(cfg.StatusVideosURL))
+	}
+
+	// Lets users enter Prowlarr URLs on the server's own network
+	opts = append(opts, addon.WithPrivateProwlarrURLs(cfg.AllowPrivateURLs))
+
+	// Seals configurations into the install URL instead of storing them
+	if len(cfg.UserDataKeys) > 0 {
+		keys, err := seal.ParseKeys(cfg.UserDataKeys)
+		if err != nil {
+			fatal("Invalid userData keys", "error", err)
+		}
+		opts = append(opts, addon.WithUserDataKeys(keys), addon.WithUnsealedUserData(cfg.AllowUnsealed))
+	}
+
+	// Keeps configurations and linked Real Debrid accounts server-side
+	db, err := store.Open(cfg.DatabasePath)
+	if err != nil {
+		fatal("Couldn't open database", "path", cfg.DatabasePath, "error", err)
+	}
+	defer db.Close()
+	opts = append(opts, addon.WithStore(db))
+
 	add := addon.New(opts...)
 
-	app.Get("/manifest.json", add.HandleGetManifest)
-	app.Get("/:userData/manifest.json", add.HandleGetManifest)
-	app.Get("/logo", add.HandleLogo)
-	app.Get("/:userData/logo", add.HandleLogo)
-	app.Get("/stream/:type/:id.json", add.HandleGetStreams)
-	app.Get("/:userData/stream/:type/:id.json", add.HandleGetStreams)
-	app.Get("/download/:infoHash/:fileID", add.HandleDownload)
-	app.Get("/:userData/download/:infoHash/:fileID", add.HandleDownload)
-	app.Head("/download/:infoHash/:fileID", add.HandleDownload)
-	app.Head("/:userData/download/:infoHash/:fileID", add.HandleDownload)
-	app.Get("/configure", static.HandleConfigure)
-	app.Get("/:userData/configure", static.HandleConfigure)
-
-	// Check if SSL is enabled
-	sslEnabled := os.Getenv("SSL_ENABLED") == "true"
-	
-	if sslEnabled {
-		// Start HTTPS server in a goroutine
-		go func() {
-			httpsApp := fiber.New(fiber.Config{
-				AppName: "StreamX SSL",
-			})
-			
-			// Add same middleware as HTTP server
-			httpsApp.Use(cors.New())
-			httpsApp.Use(recover.New(recover.Config{
-				EnableStackTrace: true,
-			}))
-			
-			// Copy all routes to HTTPS app
-			httpsApp.Get("/manifest.json", add.HandleGetManifest)
-			httpsApp.Get("/:userData/manifest.json", add.HandleGetManifest)
-			httpsApp.Get("/logo", add.HandleLogo)
-			httpsApp.Get("/:userData/logo", add.HandleLogo)
-			httpsApp.Get("/stream/:type/:id.json", add.HandleGetStreams)
-			httpsApp.Get("/:userData/stream/:type/:id.json", add.HandleGetStreams)
-			httpsApp.Get("/download/:infoHash/:fileID", add.HandleDownload)
-			httpsApp.Get("/:userData/download/:infoHash/:fileID", add.HandleDownload)
-			httpsApp.Head("/download/:infoHash/:fileID", add.HandleDownload)
-			httpsApp.Head("/:userData/download/:infoHash/:fileID", add.HandleDownload)
-			httpsApp.Get("/configure", static.HandleConfigure)
-			httpsApp.Get("/:userData/configure", static.HandleConfigure)
-			
-			// SSL certificate paths
-			certFile := "/etc/ssl/local-ip-co/server.pem"
-			keyFile := "/etc/ssl/local-ip-co/server.key"
-			
-			log.Infof("Starting HTTPS server on :7443 with SSL domain: %s", os.Getenv("SSL_DOMAIN"))
-			log.Fatal(httpsApp.ListenTLS(":7443", certFile, keyFile))
-		}()
+	// SIGTERM lets in-flight stream requests finish before exiting
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := serve(ctx, cfg.Server, add); err != nil {
+		fatal("Server failed", "error", err)
 	}
-	
-	log.Infof("Starting HTTP server on :7000")
-	log.Fatal(app.Listen(":7000"))
+	slog.Info("Server stopped")
+}
+
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
 }
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+
+	"github.com/dbytex91/streamx/internal/addon"
+	"github.com/dbytex91/streamx/internal/certs"
+	"github.com/dbytex91/streamx/internal/logging"
+	"github.com/dbytex91/streamx/internal/metrics"
+	"github.com/dbytex91/streamx/internal/static"
+	"github.com/dbytex91/streamx/internal/tracing"
+)
+
+// serverConfig configures the listeners. The read timeout also bounds idle
+// keep-alive connections so shutdowns aren't held up by them, the write
+// timeout has to leave room for the longest search timeout.
+type serverConfig struct {
+	HTTPAddr        string        `env:"HTTP_ADDR" envDefault:":7000"`
+	HTTPSAddr       string        `env:"HTTPS_ADDR" envDefault:":7443"`
+	SSLEnabled      bool          `env:"SSL_ENABLED"`
+	SSLDomain       string        `env:"SSL_DOMAIN"`
+	TLSCertFile     string        `env:"TLS_CERT_FILE" envDefault:"/etc/ssl/local-ip-co/server.pem"`
+	TLSKeyFile      string        `env:"TLS_KEY_FILE" envDefault:"/etc/ssl/local-ip-co/server.key"`
+	ReadTimeout     time.Duration `env:"READ_TIMEOUT" envDefault:"30s"`
+	WriteTimeout    time.Duration `env:"WRITE_TIMEOUT" envDefault:"150s"`
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT" envDefault:"130s"`
+	// MetricsAddr moves /metrics from the HTTP listener to its own, it's never
+	// served over HTTPS, which is what gets exposed publicly.
+	MetricsAddr string `env:"METRICS_ADDR"`
+	// TrustedProxies are the IPs or CIDRs allowed to set ProxyHeader and the
+	// Cf-Connecting-Ip header, any client is trusted when empty.
+	TrustedProxies []string `env:"TRUSTED_PROXIES"`
+	ProxyHeader    string   `env:"PROXY_HEADER"`
+	ACME           acmeConfig
+}
+
+// acmeConfig replaces the certificate files by one obtained over ACME when
+// domains are set.
+type acmeConfig struct {
+	Domains      []string `env:"ACME_DOMAINS"`
+	Email        string   `env:"ACME_EMAIL"`
+	DirectoryURL string   `env:"ACME_DIRECTORY_URL" envDefault:"https://acme-v02.api.letsencrypt.org/directory"`
+	// CACert is the root the ACME server's HTTPS certificate is checked against
+	// on top of the system ones, e.g. Pebble's test root
+	CACert                string        `env:"ACME_CA_CERT"`
+	Challenge             string        `env:"ACME_CHALLENGE" envDefault:"tls-alpn-01"`
+	CacheDir              string        `env:"ACME_CACHE_DIR" envDefault:"data/acme"`
+	RenewBefore           time.Duration `env:"ACME_RENEW_BEFORE" envDefault:"720h"`
+	DNSProvider           string        `env:"ACME_DNS_PROVIDER"`
+	DNSAPIToken           string        `env:"ACME_DNS_API_TOKEN"`
+	DNSPropagationTimeout time.Duration `env:"ACME_DNS_PROPAGATION_TIMEOUT" envDefault:"2m"`
+}
+
+// newApp creates a fiber app with the middlewares and routes of StreamX, one
+// is created per listener.
+func newApp(name string, cfg serverConfig, add *addon.Addon) *fiber.App {
+	app := fiber.New(fiber.Config{
+		AppName:                 name,
+		ReadTimeout:             cfg.ReadTimeout,
+		WriteTimeout:            cfg.WriteTimeout,
+		EnableTrustedProxyCheck: len(cfg.TrustedProxies) > 0,
+		TrustedProxies:          cfg.TrustedProxies,
+		ProxyHeader:             cfg.ProxyHeader,
+		DisableStartupMessage:   true,
+	})
+
+	app.Use(cors.New())
+	app.Use(recover.New(recover.Config{
+		EnableStackTrace: true,
+	}))
+	app.Use(logging.Middleware(pathMasker(app)))
+	app.Use(tracing.Middleware())
+
+	registerRoutes(app, add)
+	return app
+}
+
+// pathMasker hides the configuration of the user from request logs. The first
+// segment of a path is masked unless a route starts with it, which covers
+// every route prefixed with the configuration and paths of no route.
+func pathMasker(app *fiber.App) func(path string) string {
+	var once sync.Once
+	fixed := map[string]bool{}
+
+	return func(urlPath string) string {
+		// routes are all registered by the first request
+		once.Do(func() {
+			for _, route := range app.GetRoutes() {
+				first, _, _ := strings.Cut(strings.TrimPrefix(route.Path, "/"), "/")
+				if first != "" && !strings.HasPrefix(first, ":") {
+					fixed[first] = true
+				}
+			}
+		})
+
+		first, rest, found := strings.Cut(strings.TrimPrefix(urlPath, "/"), "/")
+		if first == "" || fixed[first] {
+			return urlPath
+		}
+		if !found {
+			return "/***"
+		}
+
+		return "/***/" + rest
+	}
+}
+
+func registerRoutes(app *fiber.App, add *addon.Addon) {
+	app.Get("/manifest.json", add.HandleGetManifest)
+	app.Get("/:userData/manifest.json", add.HandleGetManifest)
+	app.Get("/logo", add.HandleLogo)
+	app.Get("/:userData/logo", add.HandleLogo)
+	app.Get("/stream/:type/:id.json", add.HandleGetStreams)
+	app.Get("/:userData/stream/:type/:id.json", add.HandleGetStreams)
+	app.Get("/:userData/explain/:type/:id.json", add.HandleExplain)
+	app.Get("/download/:infoHash/:fileID", add.HandleDownload)
+	app.Get("/:userData/download/:infoHash/:fileID", add.HandleDownload)
+	app.Head("/download/:infoHash/:fileID", add.HandleDownload)
+	app.Head("/:userData/download/:infoHash/:fileID", add.HandleDownload)
+	app.Get("/request/:infoHash", add.HandleRequestDownload)
+	app.Get("/:userData/request/:infoHash", add.HandleRequestDownload)
+	app.Get("/torrent/:infoHash.torrent", add.HandleTorrentFile)
+	app.Get("/magnet/:infoHash", add.HandleMagnet)
+	app.Get("/send/:infoHash/:signature", add.HandleSendToServer)
+	app.Post("/api/config", add.HandleSaveConfig)
+	app.Post("/api/config/validate", add.HandleValidateConfig)
+	app.Post("/api/realdebrid/device", add.HandleRealDebridDevice)
+	app.Post("/api/realdebrid/link", add.HandleRealDebridLink)
+	app.Get("/healthz", add.HandleHealth)
+	app.Get("/readyz", add.HandleReady)
+	app.Get("/status/:video", static.HandleStatusVideo)
+	app.Get("/configure", static.HandleConfigure)
+	app.Get("/:userData/configure", static.HandleConfigure)
+}
+
+// serve runs the HTTP listener, and the HTTPS one when SSL is enabled, until
+// ctx is done or a listener fails. The apps then stop accepting connections
+// and in-flight requests, e.g. stream searches, get ShutdownTimeout to finish.
+func serve(ctx context.Context, cfg serverConfig, add *addon.Addon) error {
+	type listener struct {
+		app   *fiber.App
+		start func() error
+	}
+
+	httpApp := newApp("StreamX", cfg, add)
+	listeners := []listener{{
+		app: httpApp,
+		start: func() error {
+			slog.Info("Starting HTTP server", "addr", cfg.HTTPAddr)
+			return httpApp.Listen(cfg.HTTPAddr)
+		},
+	}}
+
+	if cfg.MetricsAddr == "" {
+		httpApp.Get("/metrics", metrics.Handler())
+	} else {
+		metricsApp := fiber.New(fiber.Config{AppName: "StreamX metrics", DisableStartupMessage: true})
+		metricsApp.Get("/metrics", metrics.Handler())
+		listeners = append(listeners, listener{
+			app: metricsApp,
+			start: func() error {
+				slog.Info("Starting metrics server", "addr", cfg.MetricsAddr)
+				return metricsApp.Listen(cfg.MetricsAddr)
+			},
+		})
+	}
+
+	if cfg.SSLEnabled {
+		source, err := certificateSource(ctx, cfg, httpApp)
+		if err != nil {
+			return err
+		}
+
+		httpsApp := newApp("StreamX SSL", cfg, add)
+		listeners = append(listeners, listener{
+			app: httpsApp,
+			start: func() error {
+				ln, err := net.Listen("tcp", cfg.HTTPSAddr)
+				if err != nil {
+					return err
+				}
+
+				slog.Info("Starting HTTPS server", "addr", cfg.HTTPSAddr, "domain", cfg.SSLDomain)
+				return httpsApp.Listener(tls.NewListener(ln, certs.TLSConfig(source)))
+			},
+		})
+	}
+
+	errCh := make(chan error, len(listeners))
+	for _, l := range listeners {
+		go func(start func() error) {
+			errCh <- start()
+		}(l.start)
+	}
+
+	var err error
+	select {
+	case <-ctx.Done():
+		slog.Info("Shutting down, draining in-flight requests", "timeout", cfg.ShutdownTimeout)
+	case err = <-errCh:
+		slog.Error("Server failed, shutting down", "error", err)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	for _, l := range listeners {
+		if shutdownErr := l.app.ShutdownWithContext(shutdownCtx); shutdownErr != nil {
+			err = errors.Join(err, shutdownErr)
+		}
+	}
+
+	return err
+}
+
+// certificateSource returns the certificates of the HTTPS listener. Files are
+// reloaded once replaced, while ACME certificates are obtained and renewed in
+// the background, answering HTTP-01 challenges on the HTTP app.
+func certificateSource(ctx context.Context, cfg serverConfig, httpApp *fiber.App) (certs.Source, error) {
+	if len(cfg.ACME.Domains) == 0 {
+		slog.Info("Serving certificate files", "cert", cfg.TLSCertFile)
+		return certs.LoadFiles(cfg.TLSCertFile, cfg.TLSKeyFile)
+	}
+
+	acmeCfg := certs.Config{
+		Domains:               cfg.ACME.Domains,
+		Email:                 cfg.ACME.Email,
+		DirectoryURL:          cfg.ACME.DirectoryURL,
+		CacheDir:              cfg.ACME.CacheDir,
+		Challenge:             cfg.ACME.Challenge,
+		RenewBefore:           cfg.ACME.RenewBefore,
+		DNSPropagationTimeout: cfg.ACME.DNSPropagationTimeout,
+	}
+
+	if cfg.ACME.Challenge == certs.ChallengeDNS01 {
+		provider, err := certs.NewDNSProvider(cfg.ACME.DNSProvider, cfg.ACME.DNSAPIToken)
+		if err != nil {
+			return nil, err
+		}
+		acmeCfg.DNSProvider = provider
+	}
+
+	if cfg.ACME.CACert != "" {
+		client, err := httpClientTrusting(cfg.ACME.CACert)
+		if err != nil {
+			return nil, err
+		}
+		acmeCfg.HTTPClient = client
+	}
+
+	manager, err := certs.NewManager(acmeCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	httpApp.Get("/.well-known/acme-challenge/:token", manager.HandleHTTPChallenge)
+	slog.Info("Managing certificate over ACME", "domains", cfg.ACME.Domains, "challenge", cfg.ACME.Challenge, "directory", cfg.ACME.DirectoryURL)
+	go manager.Run(ctx)
+
+	return manager, nil
+}
+
+func httpClientTrusting(caFile string) (*http.Client, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	raw, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if !pool.AppendCertsFromPEM(raw) {
+		return nil, fmt.Errorf("no certificate in %s", caFile)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	return &http.Client{Transport: transport}, nil
+}
@@ -0,0 +1,312 @@
+package main
+
+import "math/bits"
+
+// The clips are H.264 Constrained Baseline: one intra frame followed by
+// frames skipping every macroblock. Flat background macroblocks are coded as
+// Intra 16x16 DC prediction without residual and the ones with text as raw
+// I_PCM samples, so no transform or entropy coder is needed.
+const (
+	nalSPS = 7
+	nalPPS = 8
+	nalIDR = 5
+	nalP   = 1
+
+	mbI16x16DC = 3 // I_16x16_2_0_0: DC prediction, no coded block
+	mbIPCM     = 25
+
+	log2MaxFrameNum = 4
+)
+
+type bitWriter struct {
+	buf []byte
+	cur byte
+	n   uint
+}
+
+func (w *bitWriter) writeBits(v uint64, n int) {
+	for i := n - 1; i >= 0; i-- {
+		w.cur = w.cur<<1 | byte(v>>uint(i)&1)
+		w.n++
+		if w.n == 8 {
+			w.buf = append(w.buf, w.cur)
+			w.cur, w.n = 0, 0
+		}
+	}
+}
+
+func (w *bitWriter) writeFlag(b bool) {
+	if b {
+		w.writeBits(1, 1)
+	} else {
+		w.writeBits(0, 1)
+	}
+}
+
+// writeUE writes an unsigned Exp-Golomb code.
+func (w *bitWriter) writeUE(v uint64) {
+	length := bits.Len64(v + 1)
+	w.writeBits(0, length-1)
+	w.writeBits(v+1, length)
+}
+
+// writeSE writes a signed Exp-Golomb code.
+func (w *bitWriter) writeSE(v int64) {
+	if v > 0 {
+		w.writeUE(uint64(2*v - 1))
+	} else {
+		w.writeUE(uint64(-2 * v))
+	}
+}
+
+func (w *bitWriter) aligned() bool {
+	return w.n == 0
+}
+
+// trailing writes the rbsp stop bit and aligns to the next byte.
+func (w *bitWriter) trailing() []byte {
+	w.writeBits(1, 1)
+	for !w.aligned() {
+		w.writeBits(0, 1)
+	}
+
+	return w.buf
+}
+
+// nal wraps an RBSP into a NAL unit, inserting emulation prevention bytes.
+func nal(refIdc, unitType byte, rbsp []byte) []byte {
+	out := []byte{refIdc<<5 | unitType}
+	zeros := 0
+	for _, b := range rbsp {
+		if zeros == 2 && b <= 3 {
+			out = append(out, 3)
+			zeros = 0
+		}
+
+		out = append(out, b)
+		if b == 0 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+	}
+
+	return out
+}
+
+// frame is a grayscale picture padded to whole macroblocks.
+type frame struct {
+	width, height int // displayed size
+	mbWidth       int
+	mbHeight      int
+	luma          []byte
+}
+
+func newFrame(width, height int, background byte) *frame {
+	f := &frame{
+		width:    width,
+		height:   height,
+		mbWidth:  (width + 15) / 16,
+		mbHeight: (height + 15) / 16,
+	}
+	f.luma = make([]byte, f.mbWidth*16*f.mbHeight*16)
+	for i := range f.luma {
+		f.luma[i] = background
+	}
+
+	return f
+}
+
+func (f *frame) stride() int {
+	return f.mbWidth * 16
+}
+
+func (f *frame) set(x, y int, v byte) {
+	if x >= 0 && y >= 0 && x < f.width && y < f.height {
+		f.luma[y*f.stride()+x] = v
+	}
+}
+
+func (f *frame) at(x, y int) byte {
+	return f.luma[y*f.stride()+x]
+}
+
+func sps(f *frame) []byte {
+	w := &bitWriter{}
+	w.writeBits(66, 8)   // profile_idc: Baseline
+	w.writeBits(0xc0, 8) // constraint_set0 and constraint_set1: Constrained Baseline
+	w.writeBits(30, 8)   // level_idc 3.0
+	w.writeUE(0)         // seq_parameter_set_id
+	w.writeUE(log2MaxFrameNum - 4)
+	w.writeUE(2) // pic_order_cnt_type: output order is decoding order
+	w.writeUE(1) // max_num_ref_frames
+	w.writeFlag(false)
+	w.writeUE(uint64(f.mbWidth - 1))
+	w.writeUE(uint64(f.mbHeight - 1))
+	w.writeFlag(true) // frame_mbs_only_flag
+	w.writeFlag(true) // direct_8x8_inference_flag
+
+	// crop offsets count pairs of pixels in 4:2:0
+	cropRight := (f.mbWidth*16 - f.width) / 2
+	cropBottom := (f.mbHeight*16 - f.height) / 2
+	w.writeFlag(cropRight > 0 || cropBottom > 0)
+	if cropRight > 0 || cropBottom > 0 {
+		w.writeUE(0)
+		w.writeUE(uint64(cropRight))
+		w.writeUE(0)
+		w.writeUE(uint64(cropBottom))
+	}
+
+	w.writeFlag(false) // vui_parameters_present_flag
+	return nal(3, nalSPS, w.trailing())
+}
+
+func pps() []byte {
+	w := &bitWriter{}
+	w.writeUE(0)       // pic_parameter_set_id
+	w.writeUE(0)       // seq_parameter_set_id
+	w.writeFlag(false) // entropy_coding_mode_flag: CAVLC
+	w.writeFlag(false) // bottom_field_pic_order_in_frame_present_flag
+	w.writeUE(0)       // num_slice_groups_minus1
+	w.writeUE(0)       // num_ref_idx_l0_default_active_minus1
+	w.writeUE(0)       // num_ref_idx_l1_default_active_minus1
+	w.writeFlag(false) // weighted_pred_flag
+	w.writeBits(0, 2)  // weighted_bipred_idc
+	w.writeSE(0)       // pic_init_qp_minus26
+	w.writeSE(0)       // pic_init_qs_minus26
+	w.writeSE(0)       // chroma_qp_index_offset
+	w.writeFlag(true)  // deblocking_filter_control_present_flag
+	w.writeFlag(false) // constrained_intra_pred_flag
+	w.writeFlag(false) // redundant_pic_cnt_present_flag
+	return nal(3, nalPPS, w.trailing())
+}
+
+// idrSlice codes the whole frame as one intra slice.
+func idrSlice(f *frame) []byte {
+	w := &bitWriter{}
+	w.writeUE(0) // first_mb_in_slice
+	w.writeUE(7) // slice_type: I, as every slice of the picture
+	w.writeUE(0) // pic_parameter_set_id
+	w.writeBits(0, log2MaxFrameNum)
+	w.writeUE(0)       // idr_pic_id
+	w.writeFlag(false) // no_output_of_prior_pics_flag
+	w.writeFlag(false) // long_term_reference_flag
+	w.writeSE(0)       // slice_qp_delta
+	w.writeUE(1)       // disable_deblocking_filter_idc: keep the samples as they are
+
+	// total coefficients of each macroblock, the neighbours pick the table
+	// of the next coeff_token
+	totalCoeff := make([]int, f.mbWidth*f.mbHeight)
+	for mbY := 0; mbY < f.mbHeight; mbY++ {
+		for mbX := 0; mbX < f.mbWidth; mbX++ {
+			mb := mbY*f.mbWidth + mbX
+			background, flat := f.flatValue(mbX, mbY)
+			if flat && f.predictDC(mbX, mbY) == int(background) {
+				w.writeUE(mbI16x16DC)
+				w.writeUE(0) // intra_chroma_pred_mode: DC
+				w.writeSE(0) // mb_qp_delta
+				w.writeBits(coeffTokenNone(neighbourCoeffs(totalCoeff, f.mbWidth, mbX, mbY)))
+				continue
+			}
+
+			w.writeUE(mbIPCM)
+			for !w.aligned() {
+				w.writeBits(0, 1)
+			}
+			for y := 0; y < 16; y++ {
+				for x := 0; x < 16; x++ {
+					w.writeBits(uint64(f.at(mbX*16+x, mbY*16+y)), 8)
+				}
+			}
+			for range 2 * 64 {
+				w.writeBits(128, 8) // gray chroma
+			}
+			totalCoeff[mb] = 16
+		}
+	}
+
+	return nal(3, nalIDR, w.trailing())
+}
+
+// skipSlice repeats the previous frame.
+func skipSlice(f *frame, frameNum int) []byte {
+	w := &bitWriter{}
+	w.writeUE(0) // first_mb_in_slice
+	w.writeUE(5) // slice_type: P, as every slice of the picture
+	w.writeUE(0) // pic_parameter_set_id
+	w.writeBits(uint64(frameNum%(1<<log2MaxFrameNum)), log2MaxFrameNum)
+	w.writeFlag(false) // num_ref_idx_active_override_flag
+	w.writeFlag(false) // ref_pic_list_modification_flag_l0
+	w.writeFlag(false) // adaptive_ref_pic_marking_mode_flag
+	w.writeSE(0)       // slice_qp_delta
+	w.writeUE(1)       // disable_deblocking_filter_idc
+	w.writeUE(uint64(f.mbWidth * f.mbHeight))
+	return nal(2, nalP, w.trailing())
+}
+
+// flatValue returns the value of a macroblock whose samples are all the same.
+func (f *frame) flatValue(mbX, mbY int) (byte, bool) {
+	v := f.at(mbX*16, mbY*16)
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 16; x++ {
+			if f.at(mbX*16+x, mbY*16+y) != v {
+				return 0, false
+			}
+		}
+	}
+
+	return v, true
+}
+
+// predictDC is the Intra 16x16 DC prediction of a macroblock, from the
+// samples above and left of it.
+func (f *frame) predictDC(mbX, mbY int) int {
+	top, left := 0, 0
+	for i := 0; i < 16; i++ {
+		if mbY > 0 {
+			top += int(f.at(mbX*16+i, mbY*16-1))
+		}
+		if mbX > 0 {
+			left += int(f.at(mbX*16-1, mbY*16+i))
+		}
+	}
+
+	switch {
+	case mbX > 0 && mbY > 0:
+		return (top + left + 16) >> 5
+	case mbY > 0:
+		return (top + 8) >> 4
+	case mbX > 0:
+		return (left + 8) >> 4
+	default:
+		return 128
+	}
+}
+
+// neighbourCoeffs is nC of the luma DC block of a macroblock.
+func neighbourCoeffs(totalCoeff []int, mbWidth, mbX, mbY int) int {
+	switch {
+	case mbX > 0 && mbY > 0:
+		return (totalCoeff[mbY*mbWidth+mbX-1] + totalCoeff[(mbY-1)*mbWidth+mbX] + 1) >> 1
+	case mbX > 0:
+		return totalCoeff[mbY*mbWidth+mbX-1]
+	case mbY > 0:
+		return totalCoeff[(mbY-1)*mbWidth+mbX]
+	default:
+		return 0
+	}
+}
+
+// coeffTokenNone is the coeff_token of a block without coefficients.
+func coeffTokenNone(nC int) (uint64, int) {
+	switch {
+	case nC < 2:
+		return 0b1, 1
+	case nC < 4:
+		return 0b11, 2
+	case nC < 8:
+		return 0b1111, 4
+	default:
+		return 0b000011, 6
+	}
+}
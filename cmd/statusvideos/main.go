@@ -0,0 +1,67 @@
+// Command statusvideos writes the clips played while a requested Real-Debrid
+// download isn't ready, which the server bundles:
+//
+//	go run ./cmd/statusvideos
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	width  = 640
+	height = 360
+	fps    = 5
+	// seconds of each clip, players go back to the stream list at the end
+	seconds = 10
+
+	background = 16
+	foreground = 235
+	dimmed     = 160
+)
+
+var clips = []struct {
+	name  string
+	title string
+	hint  string
+}{
+	{"downloading.mp4", "DOWNLOADING ON REAL-DEBRID", "PLAY IT AGAIN IN A FEW MINUTES"},
+	{"failed.mp4", "REAL-DEBRID DOWNLOAD FAILED", "PICK ANOTHER RESULT"},
+	{"ready.mp4", "DOWNLOAD READY", "RELOAD THE STREAMS TO PLAY IT"},
+}
+
+func main() {
+	out := flag.String("out", "internal/static/videos", "directory the clips are written to")
+	flag.Parse()
+
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create %s: %v\n", *out, err)
+		os.Exit(1)
+	}
+
+	for _, clip := range clips {
+		f := newFrame(width, height, background)
+		f.drawText(clip.title, 146, 3, foreground)
+		f.drawText(clip.hint, 194, 2, dimmed)
+
+		path := filepath.Join(*out, clip.name)
+		if err := os.WriteFile(path, encode(f), 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write %s: %v\n", path, err)
+			os.Exit(1)
+		}
+	}
+}
+
+// encode returns the frame as a clip of seconds at fps.
+func encode(f *frame) []byte {
+	spsNAL, ppsNAL := sps(f), pps()
+	samples := [][]byte{sample(idrSlice(f))}
+	for i := 1; i < seconds*fps; i++ {
+		samples = append(samples, sample(skipSlice(f, i)))
+	}
+
+	return mp4(f, spsNAL, ppsNAL, samples, timescale/fps)
+}
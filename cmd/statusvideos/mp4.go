@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/binary"
+)
+
+// timescale of the movie and the track, in units per second.
+const timescale = 1000
+
+type box struct {
+	buf []byte
+}
+
+func (b *box) u8(v uint8)   { b.buf = append(b.buf, v) }
+func (b *box) u16(v uint16) { b.buf = binary.BigEndian.AppendUint16(b.buf, v) }
+func (b *box) u32(v uint32) { b.buf = binary.BigEndian.AppendUint32(b.buf, v) }
+func (b *box) raw(v []byte) { b.buf = append(b.buf, v...) }
+
+func (b *box) zeros(n int) {
+	b.buf = append(b.buf, make([]byte, n)...)
+}
+
+// fullHeader writes the version and flags of a full box.
+func (b *box) fullHeader(version uint8, flags uint32) {
+	b.u32(uint32(version)<<24 | flags)
+}
+
+func (b *box) matrix() {
+	for _, v := range []uint32{0x00010000, 0, 0, 0, 0x00010000, 0, 0, 0, 0x40000000} {
+		b.u32(v)
+	}
+}
+
+func makeBox(kind string, children ...[]byte) []byte {
+	size := 8
+	for _, c := range children {
+		size += len(c)
+	}
+
+	out := binary.BigEndian.AppendUint32(make([]byte, 0, size), uint32(size))
+	out = append(out, kind...)
+	for _, c := range children {
+		out = append(out, c...)
+	}
+
+	return out
+}
+
+// mp4 muxes the samples, each a length prefixed NAL unit, into a progressive
+// MP4 with the index first.
+func mp4(f *frame, spsNAL, ppsNAL []byte, samples [][]byte, sampleDuration uint32) []byte {
+	duration := sampleDuration * uint32(len(samples))
+
+	ftyp := &box{}
+	ftyp.raw([]byte("isom"))
+	ftyp.u32(0x200)
+	ftyp.raw([]byte("isomiso2avc1mp41"))
+
+	mvhd := &box{}
+	mvhd.fullHeader(0, 0)
+	mvhd.u32(0) // creation_time
+	mvhd.u32(0) // modification_time
+	mvhd.u32(timescale)
+	mvhd.u32(duration)
+	mvhd.u32(0x00010000) // rate
+	mvhd.u16(0x0100)     // volume
+	mvhd.zeros(10)
+	mvhd.matrix()
+	mvhd.zeros(24)
+	mvhd.u32(2) // next_track_ID
+
+	tkhd := &box{}
+	tkhd.fullHeader(0, 3) // enabled and in movie
+	tkhd.u32(0)
+	tkhd.u32(0)
+	tkhd.u32(1) // track_ID
+	tkhd.u32(0)
+	tkhd.u32(duration)
+	tkhd.zeros(8)
+	tkhd.u16(0) // layer
+	tkhd.u16(0) // alternate_group
+	tkhd.u16(0) // volume
+	tkhd.u16(0)
+	tkhd.matrix()
+	tkhd.u32(uint32(f.width) << 16)
+	tkhd.u32(uint32(f.height) << 16)
+
+	mdhd := &box{}
+	mdhd.fullHeader(0, 0)
+	mdhd.u32(0)
+	mdhd.u32(0)
+	mdhd.u32(timescale)
+	mdhd.u32(duration)
+	mdhd.u16(0x55c4) // language: und
+	mdhd.u16(0)
+
+	hdlr := &box{}
+	hdlr.fullHeader(0, 0)
+	hdlr.u32(0)
+	hdlr.raw([]byte("vide"))
+	hdlr.zeros(12)
+	hdlr.raw([]byte("VideoHandler\x00"))
+
+	vmhd := &box{}
+	vmhd.fullHeader(0, 1)
+	vmhd.zeros(8)
+
+	url := &box{}
+	url.fullHeader(0, 1) // media in the same file
+	dref := &box{}
+	dref.fullHeader(0, 0)
+	dref.u32(1)
+	dref.raw(makeBox("url ", url.buf))
+
+	avcC := &box{}
+	avcC.u8(1)            // configurationVersion
+	avcC.raw(spsNAL[1:4]) // profile, compatibility and level
+	avcC.u8(0xff)         // 4 byte NAL lengths
+	avcC.u8(0xe1)         // one SPS
+	avcC.u16(uint16(len(spsNAL)))
+	avcC.raw(spsNAL)
+	avcC.u8(1) // one PPS
+	avcC.u16(uint16(len(ppsNAL)))
+	avcC.raw(ppsNAL)
+
+	avc1 := &box{}
+	avc1.zeros(6)
+	avc1.u16(1) // data_reference_index
+	avc1.zeros(16)
+	avc1.u16(uint16(f.width))
+	avc1.u16(uint16(f.height))
+	avc1.u32(0x00480000) // 72 dpi
+	avc1.u32(0x00480000)
+	avc1.u32(0)
+	avc1.u16(1) // frame_count
+	avc1.zeros(32)
+	avc1.u16(0x0018) // depth
+	avc1.u16(0xffff)
+	avc1.raw(makeBox("avcC", avcC.buf))
+
+	stsd := &box{}
+	stsd.fullHeader(0, 0)
+	stsd.u32(1)
+	stsd.raw(makeBox("avc1", avc1.buf))
+
+	stts := &box{}
+	stts.fullHeader(0, 0)
+	stts.u32(1)
+	stts.u32(uint32(len(samples)))
+	stts.u32(sampleDuration)
+
+	// only the first sample is a key frame
+	stss := &box{}
+	stss.fullHeader(0, 0)
+	stss.u32(1)
+	stss.u32(1)
+
+	stsc := &box{}
+	stsc.fullHeader(0, 0)
+	stsc.u32(1)
+	stsc.u32(1) // first_chunk
+	stsc.u32(uint32(len(samples)))
+	stsc.u32(1)
+
+	stsz := &box{}
+	stsz.fullHeader(0, 0)
+	stsz.u32(0)
+	stsz.u32(uint32(len(samples)))
+	mdat := []byte{}
+	for _, s := range samples {
+		stsz.u32(uint32(len(s)))
+		mdat = append(mdat, s...)
+	}
+
+	// the single chunk starts right after the mdat header, which follows moov
+	moov := func(chunkOffset uint32) []byte {
+		stco := &box{}
+		stco.fullHeader(0, 0)
+		stco.u32(1)
+		stco.u32(chunkOffset)
+
+		stbl := makeBox("stbl",
+			makeBox("stsd", stsd.buf),
+			makeBox("stts", stts.buf),
+			makeBox("stss", stss.buf),
+			makeBox("stsc", stsc.buf),
+			makeBox("stsz", stsz.buf),
+			makeBox("stco", stco.buf),
+		)
+		minf := makeBox("minf", makeBox("vmhd", vmhd.buf), makeBox("dinf", makeBox("dref", dref.buf)), stbl)
+		mdia := makeBox("mdia", makeBox("mdhd", mdhd.buf), makeBox("hdlr", hdlr.buf), minf)
+		return makeBox("moov", makeBox("mvhd", mvhd.buf), makeBox("trak", makeBox("tkhd", tkhd.buf), mdia))
+	}
+
+	ftypBox := makeBox("ftyp", ftyp.buf)
+	moovBox := moov(0)
+	moovBox = moov(uint32(len(ftypBox) + len(moovBox) + 8))
+
+	out := append(ftypBox, moovBox...)
+	return append(out, makeBox("mdat", mdat)...)
+}
+
+// sample prefixes the NAL units with their length.
+func sample(nals ...[]byte) []byte {
+	out := []byte{}
+	for _, n := range nals {
+		out = binary.BigEndian.AppendUint32(out, uint32(len(n)))
+		out = append(out, n...)
+	}
+
+	return out
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/dbytex91/streamx/internal/titleparser/corpus"
+)
+
+func main() {
+	corpusPath := flag.String("corpus", "internal/titleparser/testdata/corpus.jsonl", "path to the golden corpus")
+	field := flag.String("field", "", "only list mismatches for this field")
+	showMismatches := flag.Bool("mismatches", false, "list every mismatch")
+	flag.Parse()
+
+	entries, err := corpus.Load(*corpusPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load corpus: %v\n", err)
+		os.Exit(1)
+	}
+
+	report := corpus.Evaluate(entries)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "FIELD\tCORRECT\tTOTAL\tACCURACY\n")
+	for _, f := range report.Fields {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%.1f%%\n", f.Field, f.Correct, f.Total, f.Accuracy()*100)
+	}
+	w.Flush()
+
+	if !*showMismatches && *field == "" {
+		return
+	}
+
+	fmt.Println()
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "FIELD\tEXPECTED\tACTUAL\tNAME\n")
+	for _, m := range report.Mismatches {
+		if *field != "" && m.Field != *field {
+			continue
+		}
+
+		fmt.Fprintf(w, "%s\t%q\t%q\t%s\n", m.Field, m.Expected, m.Actual, m.Name)
+	}
+	w.Flush()
+}
@@ -1,9 +1,13 @@
 package addon
 
 import (
+	"context"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
+	"math"
 	"net/url"
 	"path"
 	"regexp"
@@ -14,25 +18,52 @@ import (
 	"time"
 
 	"github.com/adrg/strutil/metrics"
+	"github.com/coocood/freecache"
 	"github.com/dbytex91/streamx/internal/cinemeta"
 	"github.com/dbytex91/streamx/internal/debrid/realdebrid"
+	"github.com/dbytex91/streamx/internal/downloader"
+	"github.com/dbytex91/streamx/internal/kitsu"
+	"github.com/dbytex91/streamx/internal/metadata"
+	stats "github.com/dbytex91/streamx/internal/metrics"
 	"github.com/dbytex91/streamx/internal/model"
 	"github.com/dbytex91/streamx/internal/pipe"
 	"github.com/dbytex91/streamx/internal/prowlarr"
+	"github.com/dbytex91/streamx/internal/seal"
+	"github.com/dbytex91/streamx/internal/store"
 	"github.com/dbytex91/streamx/internal/titleparser"
-	"github.com/coocood/freecache"
+	"github.com/dbytex91/streamx/internal/torrentmeta"
+	"github.com/dbytex91/streamx/internal/tracker"
+	"github.com/dbytex91/streamx/internal/wikidata"
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/log"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
 	cacheSize           = 50 * 1024 * 1024 // 50MB
 	downloadURLExpiry   = 5 * 60
 	maxTitleDistance    = 5
+	minTitleDistance    = 1
 	maxStreamsResult    = 5
 	infoHashCacheExpiry = 24 * 60 * 60 // 1 day
-	maxSizeInBytes      = 30 * 1 << 30 // 30GB
+	metadataCacheExpiry = 12 * 60 * 60 // 12 hours
+	scrapeCacheExpiry   = 10 * 60      // 10 minutes
+	scrapeTimeout       = 3 * time.Second
+	maxSizeInBytes      = 30 * 1 << 30  // 30GB
 	minSizeInBytes      = 100 * 1 << 20 // 100MB
+	// referenceRuntime is the runtime in minutes the size bands are meant for
+	referenceRuntime = 120
+
+	audioPreferenceWeight = 10
+
+	// maxAlternativeSearches limits the extra indexer queries made with
+	// alternative titles on top of the main one.
+	maxAlternativeSearches = 2
+	wikidataUserAgent      = "StreamX/2 (https://github.com/dbytex91/streamx)"
+
+	// strongTitleConfidence and weakTitleConfidence split parsed titles into
+	// trusted, uncertain and guessed ones for the similarity check.
+	strongTitleConfidence = 0.8
+	weakTitleConfidence   = 0.5
 )
 
 var (
@@ -46,6 +77,7 @@ var (
 	}
 
 	nonWordCharacter = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+	latinTitle       = regexp.MustCompile(`^[\p{Latin}\d\s\p{P}\p{S}]+$`)
 
 	resolutionName = map[int]string{
 		2160: "4K",
@@ -56,6 +88,18 @@ var (
 		0:    "Unknown",
 	}
 
+	// defaultPublicTrackers help Stremio find peers of torrents whose own
+	// trackers are dead or private-ish
+	defaultPublicTrackers = []string{
+		"udp://tracker.opentrackr.org:1337/announce",
+		"udp://open.stealth.si:80/announce",
+		"udp://tracker.torrent.eu.org:451/announce",
+		"udp://exodus.desync.com:6969/announce",
+		"udp://open.demonii.com:1337/announce",
+	}
+
+	supportedIDPrefixes = []string{"tt", kitsu.PrefixKitsu, kitsu.PrefixMAL}
+
 	avoidQualities = []string{
 		"cam", "camrip", "telesync", "tsrip", "hdcam", "tc", "ppvrip", "r5", "vhsscr",
 	}
@@ -68,13 +112,33 @@ type Addon struct {
 	version     string
 	description string
 
-	cinemetaClient *cinemeta.CineMeta
-	prowlarrClient *prowlarr.Prowlarr
-	prowlarrURL    string
-	prowlarrAPIKey string
-	realDebridClient *realdebrid.RealDebrid
-	realDebridAPIKey string
-	cache          *freecache.Cache
+	metadataProviders metadata.Chain
+	metadataProvider  metadata.Provider
+	animeProvider     metadata.Provider
+	wikidataClient    *wikidata.Wikidata
+	metadataFetcher   *torrentmeta.Fetcher
+	publicTrackers    []string
+	downloadClient    downloader.Client
+	downloadSecret    []byte
+	downloaderKeys    []string
+	downloads         *downloadQueue
+	store             *store.Store
+	configKey         []byte
+	configKeyMu       sync.Mutex
+	userDataKeys      *seal.Keyring
+	allowUnsealed     bool
+	allowPrivateURLs  bool
+	realDebridOAuth   *realdebrid.OAuth
+	linkLocks         linkLocks
+	statusVideosURL   string
+	cinemetaClient    *cinemeta.CineMeta
+	readiness         *readinessChecks
+	prowlarrClient    *prowlarr.Prowlarr
+	prowlarrURL       string
+	prowlarrAPIKey    string
+	realDebridClient  *realdebrid.RealDebrid
+	realDebridAPIKey  string
+	cache             *freecache.Cache
 }
 
 type Option func(*Addon)
@@ -84,39 +148,66 @@ type GetStreamsResponse struct {
 }
 
 type streamRecord struct {
-	ContentType    ContentType
-	ID             string
-	Season         int
-	Episode        int
-	BaseURL        string
-	RemoteAddress  string
-	MetaInfo       *model.MetaInfo
-	TitleInfo      *titleparser.MetaInfo
-	Indexer        *prowlarr.Indexer
-	Torrent        *prowlarr.Torrent
-	Files          []*realdebrid.File
-	MediaFile      *realdebrid.File
-	SearchBySeason bool
-	RDClient       *realdebrid.RealDebrid
-	Prowlarr       *prowlarr.Prowlarr
-	UserData       *UserData
+	// Context carries the request id of the stream request into the stages
+	Context         context.Context
+	ContentType     ContentType
+	ID              string
+	Season          int
+	Episode         int
+	AbsoluteEpisode int
+	BaseURL         string
+	RemoteAddress   string
+	MetaInfo        *model.MetaInfo
+	TitleInfo       *titleparser.MetaInfo
+	Indexer         *prowlarr.Indexer
+	Torrent         *prowlarr.Torrent
+	Files           []*realdebrid.File
+	MediaFile       *realdebrid.File
+	SearchBySeason  bool
+	RDClient        *realdebrid.RealDebrid
+	Prowlarr        *prowlarr.Prowlarr
+	UserData        *UserData
+	// Uncached is set on debrid results kept only to offer requesting their download
+	Uncached bool
+	// Spans holds the stage spans of the request
+	Spans *stageSpans
+	// Explain collects the candidates when the explain endpoint runs the pipe
+	Explain   *explanation
+	Candidate *explainCandidate
 }
 
 func New(opts ...Option) *Addon {
 	addon := &Addon{
 		description:    "Advanced torrent streaming addon requiring Prowlarr or Real Debrid",
-		cinemetaClient: cinemeta.New(),
+		wikidataClient: wikidata.New(wikidataUserAgent),
 		cache:          freecache.NewCache(cacheSize),
+		publicTrackers: defaultPublicTrackers,
+		downloads:      newDownloadQueue(),
+		cinemetaClient: cinemeta.New(),
+		readiness:      &readinessChecks{},
 	}
+	addon.realDebridOAuth = realdebrid.NewOAuth(realdebrid.OAuthURL)
+	stats.RegisterCache("addon", addon.cache)
 
 	for _, opt := range opts {
 		opt(addon)
 	}
 
+	fetcherOpts := []torrentmeta.Option{}
+	if addon.store != nil {
+		fetcherOpts = append(fetcherOpts, torrentmeta.WithStore(addon.store))
+	}
+	addon.metadataFetcher = torrentmeta.New(fetcherOpts...)
+
+	// Cinemeta needs no configuration, keep it as the last resort
+	addon.metadataProviders = append(addon.metadataProviders, addon.cinemetaClient)
+	addon.metadataProvider = metadata.NewCached(addon.metadataProviders, addon.cache, metadataCacheExpiry)
+	addon.animeProvider = metadata.NewCached(kitsu.New(), addon.cache, metadataCacheExpiry)
+
 	// At least one service (Prowlarr or Real Debrid) must be configured via environment variables
 	// If none are configured via environment, users can still configure via UI
 	if addon.prowlarrClient == nil && addon.realDebridClient == nil {
-		log.Warn("No services configured via environment variables. Users must configure via UI.")
+		slog.Warn("No services configured via environment variables, users must configure them in the UI")
 	}
 
 	return addon
@@ -125,9 +216,9 @@ func New(opts ...Option) *Addon {
 func (add *Addon) HandleGetManifest(c *fiber.Ctx) error {
 	// Check if this is the base route (/manifest.json) or userData route (/:userData/manifest.json)
 	userDataRaw := c.Params("userData")
-	
+
 	var configRequired bool
-	
+
 	if userDataRaw == "" {
 		// Base route - only require configuration if no environment clients are available
 		configRequired = add.prowlarrClient == nil && add.realDebridClient == nil
@@ -146,12 +237,12 @@ func (add *Addon) HandleGetManifest(c *fiber.Ctx) error {
 			{
 				Name:       ResourceStream,
 				Types:      []ContentType{ContentTypeMovie, ContentTypeSeries},
-				IDPrefixes: []string{"tt"},
+				IDPrefixes: supportedIDPrefixes,
 			},
 		},
 		Types:      []ContentType{ContentTypeMovie, ContentTypeSeries},
 		Catalogs:   []CatalogItem{},
-		IDPrefixes: []string{"tt"},
+		IDPrefixes: supportedIDPrefixes,
 		Logo:       c.BaseURL() + "/logo",
 		BehaviorHints: &BehaviorHints{
 			Configurable:          true,
@@ -166,7 +257,7 @@ func (add *Addon) HandleLogo(c *fiber.Ctx) error {
 	// Set content type for SVG
 	c.Set("Content-Type", "image/svg+xml")
 	c.Set("Cache-Control", "public, max-age=86400") // Cache for 24 hours
-	
+
 	// Serve the static logo file
 	return c.SendFile("/bin/logo.svg")
 }
@@ -175,17 +266,17 @@ func (add *Addon) HandleDownload(c *fiber.Ctx) error {
 	infoHash := strings.ToLower(c.Params("infoHash"))
 	fileID := strings.ToLower(c.Params("fileID"))
 	ipAddress := getIPAddress(c)
-	
+
 	// Check if this is the base route (/download/...) or userData route (/:userData/download/...)
 	userDataRaw := c.Params("userData")
-	
+
 	var userData *UserData
 	var err error
-	
+
 	if userDataRaw == "" {
 		// Base route - use environment configuration if available
 		if add.realDebridClient != nil {
-			log.Infof("Using environment configuration for download with default settings")
+			slog.InfoContext(c.UserContext(), "Using environment configuration for download with default settings")
 			userData = NewUserDataWithDefaults()
 		} else {
 			return c.Status(400).JSON(fiber.Map{
@@ -203,11 +294,8 @@ func (add *Addon) HandleDownload(c *fiber.Ctx) error {
 	}
 
 	// Use final configuration values (environment + UI overrides)
-	finalRealDebridAPIKey := userData.RDAPIKey
-	if finalRealDebridAPIKey == "" && add.realDebridAPIKey != "" {
-		finalRealDebridAPIKey = add.realDebridAPIKey
-	}
-	
+	finalRealDebridAPIKey := add.realDebridKey(userData)
+
 	var realDebrid *realdebrid.RealDebrid
 	if finalRealDebridAPIKey != "" {
 		realDebrid = realdebrid.New(finalRealDebridAPIKey, ipAddress)
@@ -220,20 +308,23 @@ func (add *Addon) HandleDownload(c *fiber.Ctx) error {
 	}
 
 	var downloadURL string
-	rawDownloadURL, err := add.cache.Get([]byte(userData.RDAPIKey + infoHash + fileID))
+	rawDownloadURL, err := add.cache.Get([]byte(finalRealDebridAPIKey + infoHash + fileID))
 	if err != nil {
-		downloadURL, err = realDebrid.GetDownloadByInfoHash(infoHash, fileID)
+		downloadURL, err = realDebrid.GetDownloadByInfoHash(c.UserContext(), infoHash, fileID)
 		if err != nil {
-			log.WithContext(c.Context()).Errorf("Couldn't generate the download link for %s, %s: %v", infoHash, fileID, err)
+			slog.ErrorContext(c.UserContext(), "Couldn't generate the download link", "info_hash", infoHash, "file_id", fileID, "error", err)
+			stats.DownloadRedirects.WithLabelValues("error", "false").Inc()
 			return err
 		}
 
-		err = add.cache.Set([]byte(userData.RDAPIKey+infoHash+fileID), []byte(downloadURL), downloadURLExpiry)
+		err = add.cache.Set([]byte(finalRealDebridAPIKey+infoHash+fileID), []byte(downloadURL), downloadURLExpiry)
 		if err != nil {
-			log.WithContext(c.Context()).Warnf("Failed to cache downloadURL: %v", err)
+			slog.WarnContext(c.UserContext(), "Failed to cache the download link", "error", err)
 		}
+		stats.DownloadRedirects.WithLabelValues("redirect", "false").Inc()
 	} else {
 		downloadURL = string(rawDownloadURL)
+		stats.DownloadRedirects.WithLabelValues("redirect", "true").Inc()
 	}
 
 	c.Response().Header.Add("Cache-control", "max-age=86400, public")
@@ -241,72 +332,47 @@ func (add *Addon) HandleDownload(c *fiber.Ctx) error {
 }
 
 func (add *Addon) HandleGetStreams(c *fiber.Ctx) error {
-	// Check if this is the base route (/stream/...) or userData route (/:userData/stream/...)
-	userDataRaw := c.Params("userData")
-	
-	var userData *UserData
-	var err error
-	
-	if userDataRaw == "" {
-		// Base route - use environment configuration if available
-		if add.prowlarrClient != nil || add.realDebridClient != nil {
-			log.Infof("Using environment configuration with optimized default settings")
-			userData = NewUserDataWithDefaults()
-		} else {
-			return c.Status(400).JSON(fiber.Map{
-				"error": "Configuration required. Please configure the addon.",
-			})
-		}
-	} else {
-		// userData route - parse userData
-		userData, err = parseUserData(add, c)
-		if err != nil {
-			log.Errorf("Failed to parse user data: %v", err)
-			return c.Status(400).JSON(fiber.Map{
-				"error": "Invalid configuration data.",
-			})
-		}
+	userData, err := add.streamUserData(c)
+	if err != nil {
+		stats.StreamRequests.WithLabelValues(metricsContentType(c), "error").Inc()
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
 	}
-	
+
 	compiled := regexp.MustCompile(`/stream/(movie|series).+$`)
-	p := pipe.New(add.sourceFromContextWithUserData(c, userData))
+	p := add.streamPipe(c, userData, nil)
 
-	p.Map(add.fetchMetaInfo)
-	p.FanOut(add.fanOutToAllIndexers)
-	p.Channel(add.searchForTorrents)
-	p.Map(add.parseTorrentTitle)
-	p.Filter(add.createExcludeTorrentsFilter())
-	// Sorting is now handled in groupByResolution
-	p.FanOut(add.enrichInfoHash, pipe.Concurrency[streamRecord](10))
-	p.Filter(deduplicateTorrent())
-	p.Batch(add.enrichWithCachedFiles)
-	p.FanOut(add.locateMediaFile)
+	start := time.Now()
+	records := add.sinkResultsWithTimeout(p, searchTimeout(userData))
+	stats.PipelineDuration.WithLabelValues(metricsContentType(c)).Observe(time.Since(start).Seconds())
 
-	// Get user-configured timeout
-	timeoutSeconds, err := strconv.Atoi(userData.SearchTimeout)
-	if err != nil || timeoutSeconds < 10 || timeoutSeconds > 120 {
-		timeoutSeconds = 45 // Default fallback
-	}
-	
-	records := add.sinkResultsWithTimeout(p, time.Duration(timeoutSeconds)*time.Second)
-	
-	// Apply user-selected sorting method
-	sortMethod := userData.SortMethod
-	if sortMethod == "" {
-		sortMethod = "quality" // Default to quality score
-	}
-	
-	if sortMethod == "quality" {
-		records = sortByQualityScore(records)
-		log.Infof("Pipeline completed - Processing %d total records (sorted by quality score)", len(records))
-	} else {
-		records = groupByResolution(records, 3)
-		log.Infof("Pipeline completed - Processing %d total records (sorted by resolution diversity)", len(records))
-	}
+	records = sortRecords(c.UserContext(), records, userData)
 	results := make([]StreamItem, 0, maxStreamsResult)
+	requestLinks := []StreamItem{}
+	downloadLinks := []StreamItem{}
 	for _, r := range records {
+		if r.Uncached {
+			if len(requestLinks) < maxStreamsResult {
+				add.rememberTorrent(r.Torrent)
+				requestURL := r.BaseURL + compiled.ReplaceAllString(c.Path(), "/request/"+r.Torrent.InfoHash)
+				requestLinks = append(requestLinks, add.requestDownloadStream(r, requestURL))
+			}
+			continue
+		}
+
+		add.rememberTorrent(r.Torrent)
+		if userData.ShowDownloadLinks == "true" {
+			downloadLinks = append(downloadLinks, add.downloadLinkStream(r))
+		}
+		if userData.SendToServer == "true" && add.downloadClient != nil {
+			if stream, ok := add.sendToServerStream(r); ok {
+				downloadLinks = append(downloadLinks, stream)
+			}
+		}
+
 		var streamItem StreamItem
-		
+
 		if r.RDClient != nil && len(r.Files) > 0 {
 			// Use debrid download URL (existing behavior)
 			streamURL := r.BaseURL + compiled.ReplaceAllString(c.Path(), "/download/"+r.Torrent.InfoHash+"/"+r.MediaFile.ID)
@@ -322,10 +388,11 @@ func (add *Addon) HandleGetStreams(c *fiber.Ctx) error {
 		} else {
 			// Use native Stremio torrent streaming (when no debrid or debrid failed)
 			streamItem = StreamItem{
-				Name:     formatStreamName(r.TitleInfo, false), // Cached = false for native torrenting
-				Title:    formatStreamTitle(r.TitleInfo, r.MediaFile.FileSize, r.Torrent.Seeders, r.Indexer.Name, false),
-				InfoHash: r.Torrent.InfoHash,
-				FileIndex: 0, // For now, use first file. Could be enhanced to find specific media file
+				Name:      formatStreamName(r.TitleInfo, false), // Cached = false for native torrenting
+				Title:     formatStreamTitle(r.TitleInfo, r.MediaFile.FileSize, r.Torrent.Seeders, r.Indexer.Name, false),
+				InfoHash:  r.Torrent.InfoHash,
+				FileIndex: nativeFileIndex(r),
+				Sources:   add.streamSources(r),
 				BehaviorHints: &StreamBehaviorHints{
 					VideoSize: r.MediaFile.FileSize,
 					FileName:  path.Base(r.MediaFile.FileName),
@@ -340,12 +407,37 @@ func (add *Addon) HandleGetStreams(c *fiber.Ctx) error {
 		}
 	}
 
-	c.Response().Header.Add("Cache-control", "max-age=1800, public, stale-while-revalidate=604800, stale-if-error=604800")
+	// download links go last so players pick a playable stream first
+	results = append(results, requestLinks...)
+	results = append(results, downloadLinks...)
+
+	result := "ok"
+	if len(results) == 0 {
+		result = "empty"
+	}
+	stats.StreamRequests.WithLabelValues(metricsContentType(c), result).Inc()
+
+	if len(requestLinks) > 0 {
+		// requested downloads show up as cached once they finish
+		c.Response().Header.Add("Cache-control", "max-age=60, private")
+	} else {
+		c.Response().Header.Add("Cache-control", "max-age=1800, public, stale-while-revalidate=604800, stale-if-error=604800")
+	}
 	return c.JSON(GetStreamsResponse{
 		Streams: results,
 	})
 }
 
+// metricsContentType keeps the type label bounded whatever clients request.
+func metricsContentType(c *fiber.Ctx) string {
+	switch contentType := ContentType(c.Params("type")); contentType {
+	case ContentTypeMovie, ContentTypeSeries:
+		return string(contentType)
+	default:
+		return "other"
+	}
+}
+
 func (add *Addon) sourceFromContext(c *fiber.Ctx) func() ([]*streamRecord, error) {
 	return func() ([]*streamRecord, error) {
 		userData, err := parseUserData(add, c)
@@ -356,51 +448,119 @@ func (add *Addon) sourceFromContext(c *fiber.Ctx) func() ([]*streamRecord, error
 	}
 }
 
+// streamUserData returns the configuration of a stream request, the defaults
+// when the route has none and the environment configures a service.
+func (add *Addon) streamUserData(c *fiber.Ctx) (*UserData, error) {
+	// Check if this is the base route (/stream/...) or userData route (/:userData/stream/...)
+	if c.Params("userData") == "" {
+		// Base route - use environment configuration if available
+		if add.prowlarrClient == nil && add.realDebridClient == nil {
+			return nil, errors.New("Configuration required. Please configure the addon.")
+		}
+
+		slog.InfoContext(c.UserContext(), "Using environment configuration with optimized default settings")
+		return NewUserDataWithDefaults(), nil
+	}
+
+	// userData route - parse userData
+	userData, err := parseUserData(add, c)
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Failed to parse user data", "error", err)
+		return nil, errors.New("Invalid configuration data.")
+	}
+
+	return userData, nil
+}
+
+// streamPipe builds the pipe finding the streams of the request. Candidates
+// are collected into explain when it's not nil.
+func (add *Addon) streamPipe(c *fiber.Ctx, userData *UserData, explain *explanation) *pipe.Pipe[streamRecord] {
+	source := add.sourceFromContextWithUserData(c, userData)
+	spans := newStageSpans()
+	// stages still running once the pipe gave up are cancelled, e.g. magnet
+	// lookups dialing peers
+	ctx, cancel := context.WithCancel(c.UserContext())
+	p := pipe.New(func() ([]*streamRecord, error) {
+		records, err := source()
+		for _, r := range records {
+			r.Context = ctx
+			r.Spans = spans
+			r.Explain = explain
+		}
+		return records, err
+	})
+	p.SetContext(ctx)
+	go func() {
+		<-p.Done()
+		cancel()
+		spans.end()
+	}()
+
+	p.Map(tracedMap("metadata", add.fetchMetaInfo))
+	p.FanOut(traced("indexers", add.fanOutToAllIndexers))
+	p.Channel(add.searchForTorrents)
+	p.Map(add.parseTorrentTitle)
+	p.Filter(add.createExcludeTorrentsFilter())
+	// Sorting is now handled in groupByResolution
+	p.FanOut(traced("info_hash", add.enrichInfoHash), pipe.Concurrency[streamRecord](10))
+	p.Filter(deduplicateTorrent())
+	p.Batch(add.enrichWithCachedFiles)
+	p.FanOut(traced("file_list", add.enrichFileList), pipe.Concurrency[streamRecord](10))
+	p.FanOut(add.locateMediaFile)
+	p.FanOut(traced("seeders", add.refreshSeeders), pipe.Concurrency[streamRecord](10))
+
+	return p
+}
+
+// searchTimeout returns the user-configured timeout of the pipe.
+func searchTimeout(userData *UserData) time.Duration {
+	timeoutSeconds, err := strconv.Atoi(userData.SearchTimeout)
+	if err != nil || timeoutSeconds < 10 || timeoutSeconds > 120 {
+		timeoutSeconds = 45 // Default fallback
+	}
+
+	return time.Duration(timeoutSeconds) * time.Second
+}
+
+// sortRecords applies the user-selected sorting method.
+func sortRecords(ctx context.Context, records []*streamRecord, userData *UserData) []*streamRecord {
+	sortMethod := userData.SortMethod
+	if sortMethod == "" {
+		sortMethod = "quality" // Default to quality score
+	}
+
+	if sortMethod == "quality" {
+		records = sortByQualityScore(records)
+	} else {
+		records = groupByResolution(records, 3)
+	}
+	slog.InfoContext(ctx, "Pipeline completed", "records", len(records), "sort", sortMethod)
+
+	return records
+}
+
 func (add *Addon) sourceFromContextWithUserData(c *fiber.Ctx, userData *UserData) func() ([]*streamRecord, error) {
 	return func() ([]*streamRecord, error) {
 		ipAddress := getIPAddress(c)
 
 		// Use final configuration values (environment + UI overrides)
-		finalProwlarrURL := userData.ProwlarrURL
-		finalProwlarrAPIKey := userData.ProwlarrAPIKey
-		finalRealDebridAPIKey := userData.RDAPIKey
-		
-		// If UI values are empty, use environment variables
-		if finalProwlarrURL == "" && add.prowlarrURL != "" {
-			finalProwlarrURL = add.prowlarrURL
-		}
-		if finalProwlarrAPIKey == "" && add.prowlarrAPIKey != "" {
-			finalProwlarrAPIKey = add.prowlarrAPIKey
-		}
-		if finalRealDebridAPIKey == "" && add.realDebridAPIKey != "" {
-			finalRealDebridAPIKey = add.realDebridAPIKey
-		}
-		
+		finalRealDebridAPIKey := add.realDebridKey(userData)
+
 		var realDebrid *realdebrid.RealDebrid
 		if finalRealDebridAPIKey != "" {
 			realDebrid = realdebrid.New(finalRealDebridAPIKey, ipAddress)
 		}
-		
-		var prowlarrClient *prowlarr.Prowlarr
-		if finalProwlarrURL != "" && finalProwlarrAPIKey != "" {
-			prowlarrClient = prowlarr.New(finalProwlarrURL, finalProwlarrAPIKey)
-		}
 
-		id := c.Params("id")
-		season := 0
-		episode := 0
+		prowlarrClient := add.prowlarrFor(userData)
+
 		contentType := ContentType(c.Params("type"))
-		if contentType == ContentTypeSeries {
-			tokens := strings.Split(id, "%3A")
-			if len(tokens) != 3 {
-				return nil, errors.New("invalid stremio id")
-			}
-			id = tokens[0]
-			season, _ = strconv.Atoi(tokens[1])
-			episode, _ = strconv.Atoi(tokens[2])
+		id, season, episode, err := parseStremioID(contentType, c.Params("id"))
+		if err != nil {
+			return nil, err
 		}
 
 		return []*streamRecord{{
+			Context:       c.UserContext(),
 			ContentType:   contentType,
 			ID:            id,
 			Season:        season,
@@ -414,31 +574,161 @@ func (add *Addon) sourceFromContextWithUserData(c *fiber.Ctx, userData *UserData
 	}
 }
 
+// prowlarrFor returns the Prowlarr client of the user. A URL set in the UI
+// only goes with the API key set in the UI, the environment key is never sent
+// to a host chosen by the user. It's nil when no Prowlarr is set.
+func (add *Addon) prowlarrFor(userData *UserData) *prowlarr.Prowlarr {
+	prowlarrURL := userData.ProwlarrURL
+	prowlarrAPIKey := userData.ProwlarrAPIKey
+	if prowlarrURL == "" {
+		prowlarrURL = add.prowlarrURL
+		if prowlarrAPIKey == "" {
+			prowlarrAPIKey = add.prowlarrAPIKey
+		}
+	}
+
+	if prowlarrURL == "" || prowlarrAPIKey == "" {
+		return nil
+	}
+
+	if prowlarrURL == add.prowlarrURL && prowlarrAPIKey == add.prowlarrAPIKey && add.prowlarrClient != nil {
+		return add.prowlarrClient
+	}
+
+	return prowlarr.New(prowlarrURL, prowlarrAPIKey)
+}
+
+// searchMetricsLabel names the indexer of r in the metrics. Indexers of
+// Prowlarr instances configured by users are all "user", their names are
+// per-user data and unbounded.
+func (add *Addon) searchMetricsLabel(r *streamRecord) string {
+	if add.prowlarrClient == nil || r.Prowlarr != add.prowlarrClient {
+		return "user"
+	}
+
+	return r.Indexer.Name
+}
+
+// parseStremioID splits a stream id into the title id and the episode. Series
+// ids are "tt1234:1:2" (season and episode) while anime ids are "kitsu:1234:5"
+// or "mal:5678:5" with a single episode number counted within the entry.
+func parseStremioID(contentType ContentType, rawID string) (string, int, int, error) {
+	id := strings.ReplaceAll(rawID, "%3A", ":")
+	if contentType != ContentTypeSeries {
+		return id, 0, 0, nil
+	}
+
+	tokens := strings.Split(id, ":")
+	if kitsu.IsAnimeID(id) {
+		if len(tokens) != 3 {
+			return "", 0, 0, errors.New("invalid stremio id")
+		}
+
+		episode, _ := strconv.Atoi(tokens[2])
+		return tokens[0] + ":" + tokens[1], 0, episode, nil
+	}
+
+	if len(tokens) != 3 {
+		return "", 0, 0, errors.New("invalid stremio id")
+	}
+
+	season, _ := strconv.Atoi(tokens[1])
+	episode, _ := strconv.Atoi(tokens[2])
+	return tokens[0], season, episode, nil
+}
+
 func (add *Addon) fetchMetaInfo(r *streamRecord) (*streamRecord, error) {
+	provider := add.metadataProvider
+	if kitsu.IsAnimeID(r.ID) {
+		provider = add.animeProvider
+	}
+
 	switch r.ContentType {
 	case ContentTypeMovie:
-		resp, err := add.cinemetaClient.GetMovieById(r.ID)
+		resp, err := provider.GetMovieById(r.Context, r.ID)
 		if err != nil {
 			return r, err
 		}
 
 		r.MetaInfo = resp
-		return r, nil
 	case ContentTypeSeries:
-		resp, err := add.cinemetaClient.GetSeriesById(r.ID)
+		resp, err := provider.GetSeriesById(r.Context, r.ID)
 		if err != nil {
 			return r, err
 		}
 
 		r.MetaInfo = resp
-		return r, nil
+		if kitsu.IsAnimeID(r.ID) {
+			// kitsu numbers episodes within the entry, sequels continue the
+			// numbering of their prequels
+			r.AbsoluteEpisode = resp.EpisodeOffset + r.Episode
+		} else {
+			r.AbsoluteEpisode = resp.AbsoluteEpisode(r.Season, r.Episode)
+		}
 	default:
 		return r, errors.New("not supported content type")
 	}
+
+	add.enrichAlternativeNames(r)
+	return r, nil
+}
+
+// enrichAlternativeNames adds the original and localized titles to the meta
+// info when the provider didn't have them. They are cached by IMDb ID like the
+// meta info, failures are only logged, the main name is enough to search.
+func (add *Addon) enrichAlternativeNames(r *streamRecord) {
+	if len(r.MetaInfo.AlternativeNames) > 0 || !strings.HasPrefix(r.ID, "tt") {
+		return
+	}
+
+	cacheKey := []byte("names:" + r.ID)
+	names := &wikidata.Names{}
+	if raw, err := add.cache.Get(cacheKey); err != nil || json.Unmarshal(raw, names) != nil {
+		names, err = add.wikidataClient.GetNamesByIMDBID(r.Context, r.ID)
+		if err != nil {
+			slog.WarnContext(r.Context, "Couldn't load alternative names", "id", r.ID, "error", err)
+			return
+		}
+
+		if raw, err := json.Marshal(names); err == nil {
+			_ = add.cache.Set(cacheKey, raw, metadataCacheExpiry)
+		}
+	}
+
+	r.MetaInfo.OriginalName = names.Original
+	r.MetaInfo.AlternativeNames = names.Alternatives
+}
+
+// searchNames returns the names to query indexers with: the main name plus
+// the original and a few alternative names written in latin script, which is
+// what release names use.
+func searchNames(metaInfo *model.MetaInfo) []string {
+	names := metaInfo.Names()
+	if len(names) == 0 {
+		return nil
+	}
+
+	result := []string{names[0]}
+	seen := map[string]bool{normalizeTitle(names[0]): true}
+	for _, name := range names[1:] {
+		if len(result) > maxAlternativeSearches {
+			break
+		}
+
+		key := normalizeTitle(name)
+		if seen[key] || !latinTitle.MatchString(name) {
+			continue
+		}
+
+		seen[key] = true
+		result = append(result, name)
+	}
+
+	return result
 }
 
 func (add *Addon) fanOutToAllIndexers(r *streamRecord) ([]*streamRecord, error) {
-	allIndexers, err := r.Prowlarr.GetAllIndexers()
+	allIndexers, err := r.Prowlarr.GetAllIndexers(r.Context)
 	if err != nil {
 		return nil, fmt.Errorf("couldn't load all indexers: %v", err)
 	}
@@ -446,7 +736,7 @@ func (add *Addon) fanOutToAllIndexers(r *streamRecord) ([]*streamRecord, error)
 	records := make([]*streamRecord, 0, len(allIndexers))
 	for _, indexer := range allIndexers {
 		if !indexer.Enable {
-			log.Infof("Skip %s as it's disabled", indexer.Name)
+			slog.DebugContext(r.Context, "Skipped disabled indexer", "indexer", indexer.Name)
 			continue
 		}
 
@@ -463,6 +753,9 @@ func (add *Addon) searchForTorrents(r *streamRecord, stopCh <-chan struct{}, out
 	var err error
 	totalRecords := 0
 
+	// found torrents keep the request context, their stages aren't part of the search
+	ctx := r.Spans.start(r.Context, "search")
+
 	isStopped := func() bool {
 		select {
 		case <-stopCh:
@@ -484,28 +777,50 @@ func (add *Addon) searchForTorrents(r *streamRecord, stopCh <-chan struct{}, out
 		}
 	}
 
-	switch r.ContentType {
-	case ContentTypeMovie:
-		torrents, err = r.Prowlarr.SearchMovieTorrents(r.Indexer, r.MetaInfo.Name)
-		if err != nil {
-			return nil
+	for _, name := range searchNames(r.MetaInfo) {
+		if isStopped() {
+			break
 		}
 
-		sendAllRecords(torrents)
-	case ContentTypeSeries:
-		torrents, err = r.Prowlarr.SearchSeriesTorrents(r.Indexer, r.MetaInfo.Name)
-		if err != nil {
-			return nil
-		}
+		switch {
+		case kitsu.IsAnimeID(r.ID):
+			start := time.Now()
+			torrents, err = r.Prowlarr.SearchAnimeTorrents(ctx, r.Indexer, name)
+			stats.ObserveSearch(add.searchMetricsLabel(r), start, err)
+			if err != nil {
+				continue
+			}
 
-		sendAllRecords(torrents)
-		if !isStopped() && len(torrents) == r.Indexer.Capabilities.LimitDefaults && r.Indexer.Capabilities.LimitDefaults > 0 {
-			torrents, _ = r.Prowlarr.SearchSeasonTorrents(r.Indexer, r.MetaInfo.Name, r.Season)
 			sendAllRecords(torrents)
+		case r.ContentType == ContentTypeMovie:
+			start := time.Now()
+			torrents, err = r.Prowlarr.SearchMovieTorrents(ctx, r.Indexer, name)
+			stats.ObserveSearch(add.searchMetricsLabel(r), start, err)
+			if err != nil {
+				continue
+			}
+
+			sendAllRecords(torrents)
+		case r.ContentType == ContentTypeSeries:
+			start := time.Now()
+			torrents, err = r.Prowlarr.SearchSeriesTorrents(ctx, r.Indexer, name)
+			stats.ObserveSearch(add.searchMetricsLabel(r), start, err)
+			if err != nil {
+				continue
+			}
+
+			sendAllRecords(torrents)
+			if !isStopped() && len(torrents) == r.Indexer.Capabilities.LimitDefaults && r.Indexer.Capabilities.LimitDefaults > 0 {
+				start := time.Now()
+				torrents, err = r.Prowlarr.SearchSeasonTorrents(ctx, r.Indexer, name, r.Season)
+				stats.ObserveSearch(add.searchMetricsLabel(r), start, err)
+				sendAllRecords(torrents)
+			}
 		}
 	}
 
-	log.Infof("Completed search from %s - Found %d torrents", r.Indexer.Name, totalRecords)
+	r.Spans.finish("search", nil, attribute.String("streamx.indexer", r.Indexer.Name), attribute.Int("streamx.torrents", totalRecords))
+	slog.InfoContext(r.Context, "Completed search", "indexer", r.Indexer.Name, "torrents", totalRecords)
 	return nil
 }
 
@@ -516,29 +831,166 @@ func (add *Addon) enrichInfoHash(r *streamRecord) ([]*streamRecord, error) {
 		infoHash, err := add.cache.Get(r.Torrent.GID)
 		if err == nil {
 			r.Torrent.InfoHash = string(infoHash)
+			stats.InfoHashCache.WithLabelValues("hit").Inc()
+		} else {
+			stats.InfoHashCache.WithLabelValues("miss").Inc()
 		}
 	}
 
-	r.Torrent, err = r.Prowlarr.FetchInfoHash(r.Torrent)
+	r.Torrent, err = r.Prowlarr.FetchInfoHash(r.Context, r.Torrent)
 	if err != nil {
-		log.Errorf("Failed to fetch InfoHash for %s due to: %v", r.Torrent.Guid, err)
+		slog.ErrorContext(r.Context, "Failed to fetch the info hash", "guid", r.Torrent.Guid, "error", err)
+		r.drop("info_hash", "fetch_failed", "the indexer download link failed, see the server logs")
 		return nil, nil
 	}
 
 	if r.Torrent.InfoHash == "" {
-		log.Warnf("Unable to find InfoHash for %s", r.Torrent.Guid)
+		slog.WarnContext(r.Context, "Unable to find the info hash", "guid", r.Torrent.Guid)
+		r.drop("info_hash", "missing", "the indexer gave neither a magnet link nor a torrent file with it")
 		return nil, nil
 	}
 
 	err = add.cache.Set(r.Torrent.GID, []byte(r.Torrent.InfoHash), infoHashCacheExpiry)
 	if err != nil {
-		log.Errorf("Failed to cache the InfoHash due to: %v", err)
+		slog.ErrorContext(r.Context, "Failed to cache the info hash", "error", err)
+		r.drop("info_hash", "cache_failed", "the info hash couldn't be cached")
+		return nil, nil
+	}
+
+	return []*streamRecord{r}, nil
+}
+
+// enrichFileList loads the file list of torrents streamed natively, so the
+// right file of a season pack is played. File lists are cached by info hash.
+func (add *Addon) enrichFileList(r *streamRecord) ([]*streamRecord, error) {
+	if (r.RDClient != nil && len(r.Files) > 0) || len(r.Torrent.FileList) > 0 || r.Prowlarr == nil {
+		return []*streamRecord{r}, nil
+	}
+
+	cacheKey := []byte("files:" + r.Torrent.InfoHash)
+	if raw, err := add.cache.Get(cacheKey); err == nil {
+		if err := json.Unmarshal(raw, &r.Torrent.FileList); err == nil {
+			return []*streamRecord{r}, nil
+		}
+	}
+
+	err := r.Prowlarr.FetchFileList(r.Context, r.Torrent)
+	if errors.Is(err, prowlarr.ErrNoTorrentFile) && r.Torrent.MagnetUri != "" {
+		// magnet-only result, ask the swarm for the metadata
+		err = add.fetchMagnetFileList(r.Context, r.Torrent)
+	}
+
+	if err != nil {
+		if !errors.Is(err, prowlarr.ErrNoTorrentFile) {
+			slog.WarnContext(r.Context, "Couldn't load the file list", "torrent", r.Torrent.Title, "error", err)
+		}
+		return []*streamRecord{r}, nil
+	}
+
+	if raw, err := json.Marshal(r.Torrent.FileList); err == nil {
+		_ = add.cache.Set(cacheKey, raw, infoHashCacheExpiry)
+	}
+
+	return []*streamRecord{r}, nil
+}
+
+func (add *Addon) fetchMagnetFileList(ctx context.Context, torrent *prowlarr.Torrent) error {
+	magnet, err := prowlarr.ParseMagnetUri(torrent.MagnetUri)
+	if err != nil {
+		return err
+	}
+
+	info, err := add.metadataFetcher.Fetch(ctx, magnet)
+	if err != nil {
+		return err
+	}
+
+	torrent.FileList = info.Files
+	torrent.Info = info
+	return nil
+}
+
+// refreshSeeders replaces the indexer's seeder count of natively streamed
+// torrents with live numbers from their trackers, as indexers are often stale.
+// Torrents left with too few seeders are dropped. Trackers reporting no seeder
+// at all often just don't track the torrent, the indexer's count is kept then.
+func (add *Addon) refreshSeeders(r *streamRecord) ([]*streamRecord, error) {
+	if (r.RDClient != nil && len(r.Files) > 0) || len(r.Torrent.Trackers) == 0 {
+		return []*streamRecord{r}, nil
+	}
+
+	stats, ok := add.scrape(r.Context, r.Torrent)
+	if !ok || stats.Seeders == 0 {
+		return []*streamRecord{r}, nil
+	}
+
+	r.Torrent.Seeders = stats.Seeders
+	r.Torrent.Peers = stats.Seeders + stats.Leechers
+
+	minSeeders, _ := strconv.Atoi(r.UserData.MinSeeders)
+	if r.Torrent.Seeders < uint(max(minSeeders, 1)) {
+		slog.InfoContext(r.Context, "Excluded torrent with too few live seeders", "torrent", r.Torrent.Title, "seeders", r.Torrent.Seeders)
+		r.drop("seeders", "live_seeders", fmt.Sprintf("%d live seeders, %d required", r.Torrent.Seeders, max(minSeeders, 1)))
 		return nil, nil
 	}
 
 	return []*streamRecord{r}, nil
 }
 
+// scrape asks every tracker of the torrent and keeps the highest numbers.
+// Results are cached briefly, ok is false when no tracker answered.
+func (add *Addon) scrape(ctx context.Context, torrent *prowlarr.Torrent) (tracker.Stats, bool) {
+	cacheKey := []byte("scrape:" + torrent.InfoHash)
+	if raw, err := add.cache.Get(cacheKey); err == nil {
+		stats := tracker.Stats{}
+		if err := json.Unmarshal(raw, &stats); err == nil {
+			return stats, true
+		}
+	}
+
+	hash, err := hex.DecodeString(torrent.InfoHash)
+	if err != nil || len(hash) != 20 {
+		return tracker.Stats{}, false
+	}
+
+	infoHash := [20]byte(hash)
+	ctx, cancel := context.WithTimeout(ctx, scrapeTimeout)
+	defer cancel()
+
+	results := make(chan *tracker.Stats)
+	trackers := tracker.Trackers(torrent.Trackers)
+	for _, trackerURL := range trackers {
+		go func(trackerURL string) {
+			stats, err := tracker.Scrape(ctx, trackerURL, infoHash)
+			if err != nil {
+				slog.DebugContext(ctx, "Scrape failed", "tracker", trackerURL, "error", err)
+			}
+			results <- stats
+		}(trackerURL)
+	}
+
+	best, ok := tracker.Stats{}, false
+	for range trackers {
+		stats := <-results
+		if stats == nil {
+			continue
+		}
+
+		ok = true
+		best.Seeders = max(best.Seeders, stats.Seeders)
+		best.Leechers = max(best.Leechers, stats.Leechers)
+		best.Completed = max(best.Completed, stats.Completed)
+	}
+
+	if ok {
+		if raw, err := json.Marshal(best); err == nil {
+			_ = add.cache.Set(cacheKey, raw, scrapeCacheExpiry)
+		}
+	}
+
+	return best, ok
+}
+
 func (add *Addon) enrichWithCachedFiles(records []*streamRecord) ([]*streamRecord, error) {
 	if len(records) == 0 {
 		return records, nil
@@ -553,31 +1005,50 @@ func (add *Addon) enrichWithCachedFiles(records []*streamRecord) ([]*streamRecor
 	infoHashs := make([]string, 0, len(records))
 	for _, record := range records {
 		if record.Torrent.InfoHash == "" {
-			log.Infof("Skipped %s due to missing infoHash", record.Torrent.Title)
+			slog.InfoContext(record.Context, "Skipped torrent without info hash", "torrent", record.Torrent.Title)
 			continue
 		}
 
 		infoHashs = append(infoHashs, record.Torrent.InfoHash)
 	}
 
-	filesByHash, err := records[0].RDClient.GetFiles(infoHashs)
+	spans := records[0].Spans
+	ctx := spans.start(records[0].Context, "debrid_cache")
+	filesByHash, err := records[0].RDClient.GetFiles(ctx, infoHashs)
 	if err != nil {
-		log.Errorf("Failed to fetch files from debrid: %v", err)
+		spans.finish("debrid_cache", err, attribute.Int("streamx.torrents", len(records)))
+		slog.ErrorContext(records[0].Context, "Failed to fetch files from debrid", "error", err)
 		// Return empty files but keep the torrents for native streaming
-		log.Infof("Debrid failed, returning %d records for native torrent streaming", len(records))
+		slog.InfoContext(records[0].Context, "Debrid failed, falling back to native torrent streaming", "records", len(records))
 		return records, nil
 	}
 
+	account := add.realDebridAccount(records[0].UserData)
 	cachedRecords := make([]*streamRecord, 0, len(records))
 	for _, r := range records {
-		if files, ok := filesByHash[r.Torrent.InfoHash]; ok {
+		files, ok := filesByHash[r.Torrent.InfoHash]
+		if !ok {
+			// downloads requested by the user are cached once they finished
+			if download, requested := add.downloads.lookup(account, r.Torrent.InfoHash); requested && download.ready() {
+				files, ok = download.files, true
+			}
+		}
+
+		if ok {
 			newR := *r
 			newR.Files = files
 			cachedRecords = append(cachedRecords, &newR)
+		} else if r.UserData.RequestDownload == "true" {
+			newR := *r
+			newR.Uncached = true
+			cachedRecords = append(cachedRecords, &newR)
+		} else {
+			r.drop("debrid", "not_cached", "not cached on Real Debrid")
 		}
 	}
 
-	log.Infof("Found %d cached from %d records", len(cachedRecords), len(records))
+	spans.finish("debrid_cache", nil, attribute.Int("streamx.torrents", len(records)), attribute.Int("streamx.cached", len(cachedRecords)))
+	slog.InfoContext(records[0].Context, "Checked debrid cache", "cached", len(cachedRecords), "records", len(records))
 	return cachedRecords, nil
 }
 
@@ -593,7 +1064,7 @@ func (add *Addon) sinkResultsWithTimeout(p *pipe.Pipe[streamRecord], timeout tim
 	}, timeout)
 
 	if err != nil {
-		log.Errorf("Error while processing: %v", err)
+		slog.ErrorContext(p.Context(), "Error while processing", "error", err)
 	}
 
 	return records
@@ -604,7 +1075,7 @@ func sortByQualityScore(records []*streamRecord) []*streamRecord {
 	slices.SortFunc(records, func(r1, r2 *streamRecord) int {
 		score1 := calculateQualityScore(r1)
 		score2 := calculateQualityScore(r2)
-		
+
 		// Sort by quality score (descending)
 		if score1 > score2 {
 			return -1
@@ -614,87 +1085,154 @@ func sortByQualityScore(records []*streamRecord) []*streamRecord {
 		}
 		return 0
 	})
-	
+
 	return records
 }
 
 // calculateQualityScore returns a weighted score for optimal speed/quality balance
 func calculateQualityScore(r *streamRecord) float64 {
+	return qualityScoreOf(r).Total
+}
+
+// qualityScore holds the components of the quality score of a torrent, each
+// from 0 to 100 but the audio bonus, and their weighted total.
+type qualityScore struct {
+	Resolution float64 `json:"resolution"`
+	Source     float64 `json:"source"`
+	Size       float64 `json:"size"`
+	Seeders    float64 `json:"seeders"`
+	Audio      float64 `json:"audio"`
+	Debrid     bool    `json:"debrid"`
+	Total      float64 `json:"total"`
+}
+
+func qualityScoreOf(r *streamRecord) qualityScore {
 	// Resolution score (0-100) - Visual quality
 	resolutionScore := float64(r.TitleInfo.Resolution) / 21.6 // 2160p = 100
 	if resolutionScore > 100 {
 		resolutionScore = 100
 	}
-	
+
 	// Source quality score (0-100) - Encoding quality
 	sourceScore := float64(getQualityScore(r.TitleInfo.Quality)) * 10 // Convert 1-10 to 10-100
-	
-	// File size score (0-100) - Optimal sizes get highest scores
-	sizeGB := float64(r.MediaFile.FileSize) / (1024 * 1024 * 1024)
+
+	// File size score (0-100) - Optimal sizes get highest scores.
+	// Sizes are compared per minute when the runtime is known, so a 45-minute
+	// episode isn't judged against movie sizes. The media file was picked
+	// from a file list of Real-Debrid or of the torrent itself.
+	sizeGB := normalizedSizeGB(r, r.MediaFile.FileSize, len(r.Files) > 0 || len(r.Torrent.FileList) > 0)
 	var sizeScore float64
-	
-	// Optimal size ranges for different resolutions
-	if r.TitleInfo.Resolution >= 2160 { // 4K
-		if sizeGB >= 15 && sizeGB <= 30 {
-			sizeScore = 100 // Optimal 4K size
-		} else if sizeGB >= 10 && sizeGB <= 40 {
-			sizeScore = 80 // Acceptable 4K size
-		} else if sizeGB >= 5 && sizeGB <= 50 {
-			sizeScore = 60 // Tolerable 4K size
-		} else {
-			sizeScore = 20 // Too small or too large
-		}
-	} else if r.TitleInfo.Resolution >= 1080 { // 1080p
-		if sizeGB >= 4 && sizeGB <= 15 {
-			sizeScore = 100 // Optimal 1080p size
-		} else if sizeGB >= 2 && sizeGB <= 20 {
-			sizeScore = 80 // Acceptable 1080p size
-		} else if sizeGB >= 1 && sizeGB <= 25 {
-			sizeScore = 60 // Tolerable 1080p size
-		} else {
-			sizeScore = 20 // Too small or too large
-		}
-	} else if r.TitleInfo.Resolution >= 720 { // 720p
-		if sizeGB >= 1 && sizeGB <= 8 {
-			sizeScore = 100 // Optimal 720p size
-		} else if sizeGB >= 0.5 && sizeGB <= 12 {
-			sizeScore = 80 // Acceptable 720p size
-		} else if sizeGB >= 0.3 && sizeGB <= 15 {
-			sizeScore = 60 // Tolerable 720p size
-		} else {
-			sizeScore = 20 // Too small or too large
-		}
-	} else { // 480p and below
-		if sizeGB >= 0.5 && sizeGB <= 4 {
-			sizeScore = 100 // Optimal 480p size
-		} else if sizeGB >= 0.2 && sizeGB <= 6 {
-			sizeScore = 80 // Acceptable 480p size
-		} else if sizeGB >= 0.1 && sizeGB <= 8 {
-			sizeScore = 60 // Tolerable 480p size
-		} else {
-			sizeScore = 20 // Too small or too large
-		}
+	band := sizeBandFor(r.TitleInfo.Resolution)
+	if sizeGB >= band.optimal[0] && sizeGB <= band.optimal[1] {
+		sizeScore = 100
+	} else if sizeGB >= band.acceptable[0] && sizeGB <= band.acceptable[1] {
+		sizeScore = 80
+	} else if sizeGB >= band.tolerable[0] && sizeGB <= band.tolerable[1] {
+		sizeScore = 60
+	} else {
+		sizeScore = 20 // Too small or too large
+	}
+
+	score := qualityScore{
+		Resolution: resolutionScore,
+		Source:     sourceScore,
+		Size:       sizeScore,
+		Audio:      audioPreferenceBonus(r),
+		// Check if using Real Debrid (has cached files)
+		Debrid: r.RDClient != nil && len(r.Files) > 0,
 	}
-	
-	// Check if using Real Debrid (has cached files)
-	usingDebrid := r.RDClient != nil && len(r.Files) > 0
-	
-	if usingDebrid {
+
+	if score.Debrid {
 		// With Real Debrid: Seeders irrelevant, focus on quality
 		// Weighted combination: Visual (50%) + Source (35%) + Size (15%)
-		totalScore := (resolutionScore * 0.5) + (sourceScore * 0.35) + (sizeScore * 0.15)
-		return totalScore
-	} else {
-		// Without Real Debrid: Seeders important for speed
-		seederScore := float64(r.Torrent.Seeders)
-		if seederScore > 100 {
-			seederScore = 100 // Cap at 100 for normalization
+		score.Total = (resolutionScore * 0.5) + (sourceScore * 0.35) + (sizeScore * 0.15) + score.Audio
+		return score
+	}
+
+	// Without Real Debrid: Seeders important for speed
+	seederScore := float64(r.Torrent.Seeders)
+	if seederScore > 100 {
+		seederScore = 100 // Cap at 100 for normalization
+	}
+	score.Seeders = seederScore
+
+	// Weighted combination: Speed (40%) + Visual (30%) + Source (20%) + Size (10%)
+	score.Total = (seederScore * 0.4) + (resolutionScore * 0.3) + (sourceScore * 0.2) + (sizeScore * 0.1) + score.Audio
+	return score
+}
+
+// sizeBand holds the optimal, acceptable and tolerable size ranges in GB of a
+// referenceRuntime minutes long video.
+type sizeBand struct {
+	optimal    [2]float64
+	acceptable [2]float64
+	tolerable  [2]float64
+}
+
+// sizeBandFor returns the size ranges of the resolution, 480p and below share one.
+func sizeBandFor(resolution int) sizeBand {
+	switch {
+	case resolution >= 2160:
+		return sizeBand{optimal: [2]float64{15, 30}, acceptable: [2]float64{10, 40}, tolerable: [2]float64{5, 50}}
+	case resolution >= 1080:
+		return sizeBand{optimal: [2]float64{4, 15}, acceptable: [2]float64{2, 20}, tolerable: [2]float64{1, 25}}
+	case resolution >= 720:
+		return sizeBand{optimal: [2]float64{1, 8}, acceptable: [2]float64{0.5, 12}, tolerable: [2]float64{0.3, 15}}
+	default:
+		return sizeBand{optimal: [2]float64{0.5, 4}, acceptable: [2]float64{0.2, 6}, tolerable: [2]float64{0.1, 8}}
+	}
+}
+
+// normalizedSizeGB scales size to what it would be for a referenceRuntime
+// minutes long video. The size is left as is when the runtime is unknown or
+// when it may cover several episodes, i.e. a season pack that isn't narrowed
+// down to a single file.
+func normalizedSizeGB(r *streamRecord, size uint64, singleFile bool) float64 {
+	sizeGB := float64(size) / (1024 * 1024 * 1024)
+	if r.ContentType == ContentTypeSeries && !singleFile && r.TitleInfo.Episode == 0 {
+		return sizeGB
+	}
+
+	runtime := expectedRuntime(r)
+	if runtime <= 0 {
+		return sizeGB
+	}
+
+	return sizeGB * referenceRuntime / float64(runtime)
+}
+
+// expectedRuntime returns the runtime in minutes of the requested movie or
+// episode, 0 when the metadata provider doesn't know it.
+func expectedRuntime(r *streamRecord) int {
+	if r.MetaInfo == nil {
+		return 0
+	}
+
+	if r.ContentType == ContentTypeSeries {
+		for _, e := range r.MetaInfo.Episodes {
+			if e.Season == r.Season && e.Episode == r.Episode && e.Runtime > 0 {
+				return e.Runtime
+			}
 		}
-		
-		// Weighted combination: Speed (40%) + Visual (30%) + Source (20%) + Size (10%)
-		totalScore := (seederScore * 0.4) + (resolutionScore * 0.3) + (sourceScore * 0.2) + (sizeScore * 0.1)
-		return totalScore
 	}
+
+	return r.MetaInfo.Runtime
+}
+
+// audioPreferenceBonus nudges releases towards the user's preferred channel layout.
+// Releases without channel information are left untouched.
+func audioPreferenceBonus(r *streamRecord) float64 {
+	preferred, _ := strconv.ParseFloat(r.UserData.PreferredChannels, 64)
+	channels, _ := strconv.ParseFloat(r.TitleInfo.AudioChannels, 64)
+	if preferred == 0 || channels == 0 {
+		return 0
+	}
+
+	if channels >= preferred {
+		return audioPreferenceWeight
+	}
+
+	return -audioPreferenceWeight
 }
 
 // groupByResolution groups torrents by resolution and takes the top N from each group
@@ -711,7 +1249,7 @@ func groupByResolution(records []*streamRecord, maxPerGroup int) []*streamRecord
 		slices.SortFunc(group, func(r1, r2 *streamRecord) int {
 			score1 := calculateQualityScore(r1)
 			score2 := calculateQualityScore(r2)
-			
+
 			if score1 > score2 {
 				return -1
 			}
@@ -757,12 +1295,20 @@ func groupByResolution(records []*streamRecord, maxPerGroup int) []*streamRecord
 
 func (add *Addon) parseTorrentTitle(r *streamRecord) (*streamRecord, error) {
 	r.TitleInfo = titleparser.Parse(r.Torrent.Title)
+	if r.Explain != nil {
+		r.Candidate = r.Explain.add(r)
+	}
 	return r, nil
 }
 
 func (add *Addon) locateMediaFile(r *streamRecord) ([]*streamRecord, error) {
-	// If no debrid client is available OR no files from debrid, use torrent data directly
+	files := r.Files
 	if r.RDClient == nil || len(r.Files) == 0 {
+		files = torrentMediaFiles(r.Torrent)
+	}
+
+	// Without debrid files nor a torrent file list, use torrent data directly
+	if len(files) == 0 {
 		r.MediaFile = &realdebrid.File{
 			ID:       r.Torrent.InfoHash,
 			FileName: r.Torrent.FileName,
@@ -771,38 +1317,44 @@ func (add *Addon) locateMediaFile(r *streamRecord) ([]*streamRecord, error) {
 		return []*streamRecord{r}, nil
 	}
 
-	// Original debrid-based file filtering logic
 	switch r.ContentType {
 	case ContentTypeMovie:
-		r.MediaFile = findMovieMediaFile(r.Files)
+		r.MediaFile = findMovieMediaFile(files)
 	case ContentTypeSeries:
 		// Season & Episode together
-		r.MediaFile = findEpisodeMediaFile(r.Files, fmt.Sprintf(`(?i)(\b|_)S?(%d|%02d)[x\.\-]?E?%02d(\b|_)`, r.Season, r.Season, r.Episode))
+		r.MediaFile = findEpisodeMediaFile(files, fmt.Sprintf(`(?i)(\b|_)S?(%d|%02d)[x\.\-]?E?%02d(\b|_)`, r.Season, r.Season, r.Episode))
 
 		if r.MediaFile == nil {
 			// Season & Episode are separate
-			r.MediaFile = findEpisodeMediaFile(r.Files, fmt.Sprintf(`(?i)\bS?%02d\b.+\bE?%02d\b`, r.Season, r.Episode))
+			r.MediaFile = findEpisodeMediaFile(files, fmt.Sprintf(`(?i)\bS?%02d\b.+\bE?%02d\b`, r.Season, r.Episode))
+		}
+
+		if r.MediaFile == nil && r.AbsoluteEpisode > 0 {
+			// Fansub absolute numbering, e.g. "Title - 28 [1080p].mkv"
+			r.MediaFile = findEpisodeMediaFile(files, fmt.Sprintf(`(?i)(?:\s-\s|\bE|\bEP)0*%d(?:v\d)?\b`, r.AbsoluteEpisode))
 		}
 
 		if r.MediaFile == nil {
 			// Episode only
-			r.MediaFile = findEpisodeMediaFile(r.Files, fmt.Sprintf(`(?i)\bE?(%d|%02d)\b`, r.Episode, r.Episode))
+			r.MediaFile = findEpisodeMediaFile(files, fmt.Sprintf(`(?i)\bE?(%d|%02d)\b`, r.Episode, r.Episode))
 		}
 	default:
 		return nil, errors.New("invalid content type")
 	}
 
 	if r.MediaFile == nil {
-		log.Infof("Couldn't locate media file: %s, %d, %d", r.Torrent.Title, r.Season, r.Episode)
+		slog.InfoContext(r.Context, "Couldn't locate media file", "torrent", r.Torrent.Title, "season", r.Season, "episode", r.Episode)
+		r.drop("media_file", "not_found", fmt.Sprintf("no media file for the episode among %d files", len(files)))
 		return nil, nil
 	}
 
 	if r.MediaFile.FileSize >= maxSizeInBytes || r.MediaFile.FileSize < minSizeInBytes {
-		log.Debugf("Excluded %s due to file size: %s (outside range %s - %s)", 
-			r.Torrent.Title, 
-			bytesConvert(r.MediaFile.FileSize),
-			bytesConvert(minSizeInBytes),
-			bytesConvert(maxSizeInBytes))
+		slog.DebugContext(r.Context, "Excluded torrent due to file size",
+			"torrent", r.Torrent.Title,
+			"size", bytesConvert(r.MediaFile.FileSize),
+			"min", bytesConvert(minSizeInBytes),
+			"max", bytesConvert(maxSizeInBytes))
+		r.drop("media_file", "size", fmt.Sprintf("file %s outside %s-%s", bytesConvert(r.MediaFile.FileSize), bytesConvert(minSizeInBytes), bytesConvert(maxSizeInBytes)))
 		return nil, nil
 	}
 
@@ -813,12 +1365,14 @@ func deduplicateTorrent() func(r *streamRecord) bool {
 	found := &sync.Map{}
 	return func(r *streamRecord) bool {
 		if r.Torrent.InfoHash == "" {
-			log.Infof("Skipped %s due to empty hash", r.Torrent.Title)
+			slog.InfoContext(r.Context, "Skipped torrent without info hash", "torrent", r.Torrent.Title)
+			r.drop("dedupe", "missing_info_hash", "")
 			return false
 		}
 
 		if _, loaded := found.LoadOrStore(r.Torrent.InfoHash, struct{}{}); loaded {
-			log.Infof("Skipped %s due to duplication of %s", r.Torrent.Title, r.Torrent.InfoHash)
+			slog.InfoContext(r.Context, "Skipped duplicated torrent", "torrent", r.Torrent.Title, "info_hash", r.Torrent.InfoHash)
+			r.drop("dedupe", "duplicate", "same info hash as another result")
 			return false
 		}
 
@@ -826,6 +1380,56 @@ func deduplicateTorrent() func(r *streamRecord) bool {
 	}
 }
 
+// torrentMediaFiles lists the files of the torrent file list with their index
+// in the torrent as ID, padding files are left out.
+func torrentMediaFiles(torrent *prowlarr.Torrent) []*realdebrid.File {
+	files := make([]*realdebrid.File, 0, len(torrent.FileList))
+	for i, f := range torrent.FileList {
+		if f.Padding {
+			continue
+		}
+
+		files = append(files, &realdebrid.File{
+			ID:       strconv.Itoa(i),
+			FileName: f.Path,
+			FileSize: uint64(f.Length),
+		})
+	}
+
+	return files
+}
+
+// streamSources lists the trackers of the torrent for Stremio's engine, plus
+// the public trackers when the user asked for them, and DHT as last resort.
+func (add *Addon) streamSources(r *streamRecord) []string {
+	trackers := tracker.Trackers(r.Torrent.Trackers)
+	if r.UserData.PublicTrackers == "true" {
+		trackers = tracker.Trackers([][]string{trackers, add.publicTrackers})
+	}
+
+	sources := make([]string, 0, len(trackers)+1)
+	for _, t := range trackers {
+		sources = append(sources, "tracker:"+t)
+	}
+
+	return append(sources, "dht:"+r.Torrent.InfoHash)
+}
+
+// nativeFileIndex returns the index of the media file within the torrent, or
+// nil to let the player pick when the file list is unknown.
+func nativeFileIndex(r *streamRecord) *int {
+	if len(r.Torrent.FileList) == 0 {
+		return nil
+	}
+
+	index, err := strconv.Atoi(r.MediaFile.ID)
+	if err != nil {
+		return nil
+	}
+
+	return &index
+}
+
 func findEpisodeMediaFile(files []*realdebrid.File, pattern string) *realdebrid.File {
 	var mediaFile *realdebrid.File
 	compiled := regexp.MustCompile(pattern)
@@ -870,19 +1474,27 @@ func hasMediaExtension(fileName string) bool {
 
 func (add *Addon) createExcludeTorrentsFilter() func(r *streamRecord) bool {
 	return func(r *streamRecord) bool {
-		return excludeTorrents(r)
+		reason, detail := exclusionReason(r)
+		if reason != "" {
+			r.drop("filter", reason, detail)
+			return false
+		}
+
+		return true
 	}
 }
 
-func excludeTorrents(r *streamRecord) bool {
+// exclusionReason runs the user's filters and the content matching on the
+// torrent. It returns the first failed check, empty when the torrent is kept,
+// with a detail message for the explain endpoint.
+func exclusionReason(r *streamRecord) (string, string) {
 	// Parse user preferences with defaults
 	minRes, _ := strconv.Atoi(r.UserData.MinResolution)
 	maxRes, _ := strconv.Atoi(r.UserData.MaxResolution)
 	minSizeGB, _ := strconv.ParseFloat(r.UserData.MinSize, 64)
 	maxSizeGB, _ := strconv.ParseFloat(r.UserData.MaxSize, 64)
 	minSeeders, _ := strconv.Atoi(r.UserData.MinSeeders)
-	
-	
+
 	// Use defaults if not set or invalid
 	if minSizeGB == 0 {
 		minSizeGB = 0.1 // 100MB
@@ -893,11 +1505,11 @@ func excludeTorrents(r *streamRecord) bool {
 	if minSeeders == 0 {
 		minSeeders = 1
 	}
-	
+
 	// Convert GB to bytes
 	minSizeBytes := uint64(minSizeGB * 1024 * 1024 * 1024)
 	maxSizeBytes := uint64(maxSizeGB * 1024 * 1024 * 1024)
-	
+
 	// Quality filtering - use user preferences or defaults
 	var excludedQualities []string
 	if r.UserData.ExcludedQualities != "" {
@@ -908,12 +1520,32 @@ func excludeTorrents(r *streamRecord) bool {
 	} else {
 		excludedQualities = avoidQualities
 	}
-	
+
 	qualityOK := !slices.Contains(excludedQualities, r.TitleInfo.Quality) && !r.TitleInfo.ThreeD
-	
+
+	// Audio filtering - drop codecs/object formats the user's device can't decode
+	audioOK := true
+	if r.UserData.ExcludedAudio != "" {
+		for _, a := range strings.Split(strings.ToLower(r.UserData.ExcludedAudio), ",") {
+			a = strings.TrimSpace(a)
+			if a != "" && (a == r.TitleInfo.Audio || a == r.TitleInfo.AudioObject) {
+				audioOK = false
+				break
+			}
+		}
+	}
+
 	// File size filtering
 	sizeOK := uint64(r.Torrent.Size) >= minSizeBytes && uint64(r.Torrent.Size) <= maxSizeBytes
-	
+	sizeDetail := fmt.Sprintf("size %s outside %s-%s", bytesConvert(uint64(r.Torrent.Size)), bytesConvert(minSizeBytes), bytesConvert(maxSizeBytes))
+
+	// Runtime sanity check - a release far too small for its runtime and
+	// resolution is fake, truncated or mislabelled
+	if sizeOK && expectedRuntime(r) > 0 {
+		sizeOK = normalizedSizeGB(r, uint64(r.Torrent.Size), false) >= sizeBandFor(r.TitleInfo.Resolution).tolerable[0]
+		sizeDetail = fmt.Sprintf("size %s too small for a %d minutes runtime", bytesConvert(uint64(r.Torrent.Size)), expectedRuntime(r))
+	}
+
 	// Resolution filtering - use user preferences
 	resolutionOK := true
 	if minRes > 0 && r.TitleInfo.Resolution > 0 && r.TitleInfo.Resolution < minRes {
@@ -923,29 +1555,82 @@ func excludeTorrents(r *streamRecord) bool {
 		resolutionOK = false
 	}
 	// If maxRes is 0 (unset), don't filter by max resolution
-	
+
 	// Content matching
-	imdbOK := (r.Torrent.Imdb == 0 || r.Torrent.Imdb == r.MetaInfo.IMDBID)
+	imdbOK := (r.Torrent.Imdb == 0 || r.MetaInfo.IMDBID == 0 || r.Torrent.Imdb == r.MetaInfo.IMDBID)
 	yearOK := (r.TitleInfo.Year == 0 || (r.MetaInfo.FromYear <= r.TitleInfo.Year && r.MetaInfo.ToYear >= r.TitleInfo.Year))
-	seasonOK := r.ContentType != ContentTypeSeries || (r.TitleInfo.FromSeason == 0 || (r.TitleInfo.FromSeason <= r.Season && r.TitleInfo.ToSeason >= r.Season))
-	episodeOK := r.ContentType != ContentTypeSeries || (r.TitleInfo.Episode == 0 || r.TitleInfo.Episode == r.Episode)
-	
+	// Anime ids carry no season (r.Season == 0), so only the episode can be checked
+	seasonOK := r.ContentType != ContentTypeSeries || r.Season == 0 || (r.TitleInfo.FromSeason == 0 || (r.TitleInfo.FromSeason <= r.Season && r.TitleInfo.ToSeason >= r.Season))
+	absoluteOK := r.AbsoluteEpisode > 0 && r.TitleInfo.FromSeason == 0 && r.TitleInfo.Episode == r.AbsoluteEpisode
+	episodeOK := r.ContentType != ContentTypeSeries || (r.TitleInfo.Episode == 0 || r.TitleInfo.Episode == r.Episode || absoluteOK)
+
 	// Seeders check
 	seedersOK := r.Torrent.Seeders >= uint(minSeeders)
-	
-	torrentOK := qualityOK && sizeOK && resolutionOK && imdbOK && yearOK && seasonOK && episodeOK && seedersOK
-	
+
+	switch {
+	case !qualityOK:
+		return "quality", fmt.Sprintf("quality %q or 3D is excluded", r.TitleInfo.Quality)
+	case !audioOK:
+		return "audio", fmt.Sprintf("audio %q is excluded", strings.TrimSpace(r.TitleInfo.Audio+" "+r.TitleInfo.AudioObject))
+	case !sizeOK:
+		return "size", sizeDetail
+	case !resolutionOK:
+		return "resolution", fmt.Sprintf("resolution %s outside the preferences", formatResolution(r.TitleInfo.Resolution))
+	case !imdbOK:
+		return "imdb", fmt.Sprintf("imdb id %d instead of %d", r.Torrent.Imdb, r.MetaInfo.IMDBID)
+	case !yearOK:
+		return "year", fmt.Sprintf("year %d outside %d-%d", r.TitleInfo.Year, r.MetaInfo.FromYear, r.MetaInfo.ToYear)
+	case !seasonOK:
+		return "season", fmt.Sprintf("seasons %d-%d instead of %d", r.TitleInfo.FromSeason, r.TitleInfo.ToSeason, r.Season)
+	case !episodeOK:
+		return "episode", fmt.Sprintf("episode %d instead of %d", r.TitleInfo.Episode, r.Episode)
+	case !seedersOK:
+		return "seeders", fmt.Sprintf("%d seeders, %d required", r.Torrent.Seeders, minSeeders)
+	}
+
 	// Title similarity check for torrents without IMDB ID
-	if torrentOK && r.Torrent.Imdb == 0 {
-		diff := checkTitleSimilarity(r.MetaInfo.Name, r.TitleInfo.Title)
-		torrentOK = torrentOK && diff < maxTitleDistance
-		if !torrentOK && (diff < maxTitleDistance+3) {
-			log.Infof("Excluded %s, title: %s, diff: %d", r.Torrent.Title, r.TitleInfo.Title, diff)
+	if r.Torrent.Imdb == 0 || r.MetaInfo.IMDBID == 0 {
+		diff := checkTitleSimilarityToAny(r.MetaInfo.Names(), r.TitleInfo.Title)
+		maxDistance := titleDistanceLimit(r.TitleInfo.Confidence(titleparser.FieldTitle))
+		if diff >= maxDistance {
+			if diff < maxTitleDistance+3 {
+				slog.InfoContext(r.Context, "Excluded torrent due to title distance", "torrent", r.Torrent.Title, "title", r.TitleInfo.Title, "distance", diff, "max", maxDistance)
+			}
+			return "title_distance", fmt.Sprintf("title %q at distance %d, below %d required", r.TitleInfo.Title, diff, maxDistance)
 		}
 	}
 
-	
-	return torrentOK
+	return "", ""
+}
+
+// titleDistanceLimit returns the allowed title distance for a parsed title:
+// the less sure the parser is about where the title ends, the closer it has to
+// match the real name.
+func titleDistanceLimit(confidence float64) int {
+	switch {
+	case confidence >= strongTitleConfidence:
+		return maxTitleDistance
+	case confidence >= weakTitleConfidence:
+		return (maxTitleDistance + minTitleDistance) / 2
+	default:
+		return minTitleDistance
+	}
+}
+
+// checkTitleSimilarityToAny returns the smallest distance between the title and
+// any of the names, so a release named after the original or a localized
+// title matches as well as one using the main name.
+func checkTitleSimilarityToAny(names []string, title string) int {
+	best := math.MaxInt
+	for _, name := range names {
+		best = min(best, checkTitleSimilarity(name, title))
+	}
+
+	return best
+}
+
+func normalizeTitle(title string) string {
+	return strings.ToLower(nonWordCharacter.ReplaceAllString(title, ""))
 }
 
 func checkTitleSimilarity(left, right string) int {
@@ -960,7 +1645,6 @@ func checkTitleSimilarity(left, right string) int {
 	return metrics.Distance(left, right)
 }
 
-
 // getQualityScore returns a score for quality preference (higher = better)
 func getQualityScore(quality string) int {
 	switch quality {
@@ -985,8 +1669,13 @@ func getQualityScore(quality string) int {
 	}
 }
 
-
+// getIPAddress returns the client IP Cloudflare forwarded, ignored unless the
+// request came through a trusted proxy.
 func getIPAddress(c *fiber.Ctx) string {
+	if !c.IsProxyTrusted() {
+		return ""
+	}
+
 	ips := c.GetReqHeaders()["Cf-Connecting-Ip"]
 	if len(ips) > 0 {
 		return ips[0]
@@ -998,78 +1687,113 @@ func getIPAddress(c *fiber.Ctx) string {
 func parseUserData(add *Addon, c *fiber.Ctx) (*UserData, error) {
 	userDataRaw := c.Params("userData")
 	if userDataRaw == "" {
-		log.Errorf("No userData parameter provided")
+		slog.ErrorContext(c.UserContext(), "No userData parameter provided")
 		return nil, errors.New("configuration is required")
 	}
 
-	userDataJson, err := url.PathUnescape(userDataRaw)
-	if err != nil {
-		log.Errorf("Failed URL decode userdata %s: %v", userDataRaw, err)
-		return nil, errors.New("invalid userData")
-	}
+	userData := &UserData{}
+	if seal.IsSealed(userDataRaw) {
+		if err := add.openConfig(userDataRaw, userData); err != nil {
+			slog.ErrorContext(c.UserContext(), "Failed to open sealed configuration", "error", err)
+			return nil, errors.New("invalid userData")
+		}
+	} else if configTokenPattern.MatchString(userDataRaw) {
+		if err := add.loadConfig(userDataRaw, userData); err != nil {
+			slog.ErrorContext(c.UserContext(), "Failed to load configuration", "error", err)
+			return nil, errors.New("invalid userData")
+		}
+	} else if add.userDataKeys != nil && !add.allowUnsealed {
+		// only configurations sealed or stored by the server are accepted
+		slog.WarnContext(c.UserContext(), "Rejected a configuration in the URL, only sealed ones are accepted")
+		return nil, errors.New("unsealed userData")
+	} else {
+		userDataJson, err := url.PathUnescape(userDataRaw)
+		if err != nil {
+			slog.ErrorContext(c.UserContext(), "Failed to URL decode userData", "error", err)
+			return nil, errors.New("invalid userData")
+		}
 
-	log.Infof("Parsing user data: %s", userDataJson)
+		err = json.Unmarshal([]byte(userDataJson), userData)
+		if err != nil {
+			slog.ErrorContext(c.UserContext(), "Failed to unmarshal userData", "error", err)
+			return nil, errors.New("invalid userData")
+		}
 
-	userData := &UserData{}
-	err = json.Unmarshal([]byte(userDataJson), userData)
-	if err != nil {
-		log.Errorf("Failed JSON unmarshal userdata %s: %v", userDataJson, err)
-		return nil, errors.New("invalid userData")
+		slog.WarnContext(c.UserContext(), "Configuration in the URL is deprecated, reinstall the addon from the configure page")
 	}
 
+	if err := add.validateUserData(c.UserContext(), userData); err != nil {
+		return nil, err
+	}
+
+	return userData, nil
+}
+
+// validateUserData applies the defaults and checks that the configuration
+// and the environment together give at least one usable service.
+func (add *Addon) validateUserData(ctx context.Context, userData *UserData) error {
 	// Apply defaults for any missing values
 	userData.ApplyDefaults()
 
-	log.Infof("Parsed user data: ProwlarrURL=%s, ProwlarrAPIKey=%s, RDAPIKey=%s", 
-		userData.ProwlarrURL, 
-		userData.ProwlarrAPIKey, 
-		userData.RDAPIKey)
+	slog.DebugContext(ctx, "Parsed user data",
+		"prowlarr_url", userData.ProwlarrURL != "",
+		"prowlarr_api_key", userData.ProwlarrAPIKey != "",
+		"rd_api_key", userData.RDAPIKey != "",
+		"rd_link", userData.RDLink != "")
 
 	// Determine final configuration (environment variables + UI overrides)
 	finalProwlarrURL := userData.ProwlarrURL
 	finalProwlarrAPIKey := userData.ProwlarrAPIKey
 	finalRealDebridAPIKey := userData.RDAPIKey
-	
-	// If UI values are empty, use environment variables
+
+	// If UI values are empty, use environment variables. The environment key
+	// only goes with the environment URL.
 	if finalProwlarrURL == "" && add.prowlarrURL != "" {
 		finalProwlarrURL = add.prowlarrURL
-	}
-	if finalProwlarrAPIKey == "" && add.prowlarrAPIKey != "" {
-		finalProwlarrAPIKey = add.prowlarrAPIKey
+		if finalProwlarrAPIKey == "" {
+			finalProwlarrAPIKey = add.prowlarrAPIKey
+		}
 	}
 	if finalRealDebridAPIKey == "" && add.realDebridAPIKey != "" {
 		finalRealDebridAPIKey = add.realDebridAPIKey
 	}
-	
+
 	// Validate Prowlarr configuration consistency
-	// If user provides URL or API key in UI, both must be provided (or use env for missing one)
-	if (userData.ProwlarrURL != "" && userData.ProwlarrAPIKey == "") ||
-	   (userData.ProwlarrURL == "" && userData.ProwlarrAPIKey != "") {
-		// Check if we can fill the missing value from environment
-		if userData.ProwlarrURL != "" && add.prowlarrAPIKey != "" {
-			log.Infof("Using UI Prowlarr URL with environment API key")
-		} else if userData.ProwlarrAPIKey != "" && add.prowlarrURL != "" {
-			log.Infof("Using UI Prowlarr API key with environment URL")
+	// A URL provided in the UI needs its API key, an API key alone may go with the environment URL
+	if userData.ProwlarrURL != "" && userData.ProwlarrAPIKey == "" {
+		slog.ErrorContext(ctx, "Prowlarr URL provided without its API key")
+		return errors.New("prowlarr URL and API key must be provided together")
+	}
+	if userData.ProwlarrURL != "" {
+		if err := add.checkProwlarrURL(ctx, userData.ProwlarrURL); err != nil {
+			slog.WarnContext(ctx, "Rejected the Prowlarr URL of a configuration", "error", err)
+			return err
+		}
+	}
+	if userData.ProwlarrURL == "" && userData.ProwlarrAPIKey != "" {
+		if add.prowlarrURL != "" {
+			slog.DebugContext(ctx, "Using UI Prowlarr API key with environment URL")
 		} else {
-			log.Errorf("Both Prowlarr URL and API key must be provided together")
-			return nil, errors.New("prowlarr URL and API key must be provided together")
+			slog.ErrorContext(ctx, "Both Prowlarr URL and API key must be provided together")
+			return errors.New("prowlarr URL and API key must be provided together")
 		}
 	}
 
 	// Validate that at least one service is configured
 	prowlarrConfigured := (finalProwlarrURL != "" && finalProwlarrAPIKey != "")
-	realDebridConfigured := (finalRealDebridAPIKey != "")
-	
+	realDebridConfigured := (finalRealDebridAPIKey != "" || userData.RDLink != "")
+
 	if !prowlarrConfigured && !realDebridConfigured {
-		log.Errorf("No services configured: Prowlarr or Real Debrid required")
-		return nil, errors.New("prowlarr or real debrid configuration is required")
+		slog.ErrorContext(ctx, "No services configured, Prowlarr or Real Debrid required")
+		return errors.New("prowlarr or real debrid configuration is required")
 	}
-	
-	log.Infof("Final configuration - Prowlarr: %v (URL: %v, APIKey: %v), Real Debrid: %v (APIKey: %v)", 
-		prowlarrConfigured, finalProwlarrURL, finalProwlarrAPIKey != "",
-		realDebridConfigured, finalRealDebridAPIKey != "")
 
-	return userData, nil
+	slog.DebugContext(ctx, "Final configuration",
+		"prowlarr", prowlarrConfigured,
+		"prowlarr_url", finalProwlarrURL,
+		"real_debrid", realDebridConfigured)
+
+	return nil
 }
 
 func formatResolution(resolution int) string {
@@ -1086,20 +1810,20 @@ func formatStreamName(titleInfo *titleparser.MetaInfo, isCached bool) string {
 	if isCached {
 		cachedIndicator = " ⚡"
 	}
-	
+
 	firstLine := fmt.Sprintf("StreamX%s", cachedIndicator)
 	lines := []string{firstLine}
-	
+
 	// Always add resolution in brackets on second line
 	resolution := formatResolution(titleInfo.Resolution)
 	lines = append(lines, fmt.Sprintf("[%s]", resolution))
-	
+
 	// Add codec in brackets on third line only if available
 	if titleInfo.Codec != "" {
 		codec := strings.ToUpper(titleInfo.Codec)
 		lines = append(lines, fmt.Sprintf("[%s]", codec))
 	}
-	
+
 	return strings.Join(lines, "\n")
 }
 
@@ -1114,12 +1838,12 @@ func formatStreamTitle(titleInfo *titleparser.MetaInfo, fileSize uint64, seeders
 		// Clean up multiple spaces
 		cleanTitle = strings.Join(strings.Fields(cleanTitle), " ")
 	}
-	
+
 	// Add year if available
 	if titleInfo.Year > 0 {
 		cleanTitle = fmt.Sprintf("%s (%d)", cleanTitle, titleInfo.Year)
 	}
-	
+
 	// Add season/episode info if available
 	if titleInfo.FromSeason > 0 && titleInfo.Episode > 0 {
 		cleanTitle = fmt.Sprintf("%s S%02dE%02d", cleanTitle, titleInfo.FromSeason, titleInfo.Episode)
@@ -1130,12 +1854,12 @@ func formatStreamTitle(titleInfo *titleparser.MetaInfo, fileSize uint64, seeders
 			cleanTitle = fmt.Sprintf("%s S%02d", cleanTitle, titleInfo.FromSeason)
 		}
 	}
-	
+
 	// Format with emojis: Seeders | Size | Quality
-	info := fmt.Sprintf("👤 %d | 💾 %s", 
+	info := fmt.Sprintf("👤 %d | 💾 %s",
 		seeders,
 		bytesConvert(fileSize))
-	
+
 	// Add quality after size if available
 	if titleInfo.Quality != "" {
 		quality := strings.ToUpper(titleInfo.Quality)
@@ -1143,17 +1867,42 @@ func formatStreamTitle(titleInfo *titleparser.MetaInfo, fileSize uint64, seeders
 		quality = strings.ReplaceAll(quality, "_", " ")
 		info = fmt.Sprintf("%s | [%s]", info, quality)
 	}
-	
+
 	// Add provider as third line
 	provider := fmt.Sprintf("🔍 %s", indexerName)
-	
-	// Add language as fourth line if available
+
 	lines := []string{cleanTitle, info, provider}
+
+	// Add audio details if available
+	if audio := formatAudio(titleInfo); audio != "" {
+		lines = append(lines, fmt.Sprintf("🔊 %s", audio))
+	}
+
+	// Add language as last line if available
 	if titleInfo.Language != "" {
 		language := strings.ToUpper(titleInfo.Language)
 		lines = append(lines, fmt.Sprintf("🌍 %s", language))
 	}
-	
+
 	return strings.Join(lines, "\n")
 }
 
+func formatAudio(titleInfo *titleparser.MetaInfo) string {
+	parts := []string{}
+	if titleInfo.Audio != "" {
+		parts = append(parts, strings.ToUpper(titleInfo.Audio))
+	}
+	if titleInfo.AudioChannels != "" {
+		parts = append(parts, titleInfo.AudioChannels)
+	}
+	if titleInfo.AudioObject != "" {
+		parts = append(parts, strings.ToUpper(titleInfo.AudioObject))
+	}
+	if titleInfo.MultiAudio {
+		parts = append(parts, "Multi")
+	} else if titleInfo.DualAudio {
+		parts = append(parts, "Dual")
+	}
+
+	return strings.Join(parts, " ")
+}
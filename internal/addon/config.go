@@ -0,0 +1,131 @@
+package addon
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"regexp"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/dbytex91/streamx/internal/store"
+)
+
+const (
+	configsBucket = "configs"
+	// secretsBucket keeps the key deriving configuration tokens
+	secretsBucket = "secrets"
+	configKeyName = "configs"
+	// maxConfigSize bounds stored configurations, real ones take a few hundred
+	// bytes
+	maxConfigSize = 4 << 10
+)
+
+// configTokenPattern matches the opaque tokens standing for a stored
+// configuration, legacy configurations are URL-encoded JSON objects.
+var configTokenPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// HandleSaveConfig turns the configuration posted by the configure page into
+// the token used in the install URL, so API keys stay out of manifest URLs and
+// access logs. With userData keys the token is the sealed configuration,
+// otherwise it refers to the stored one, derived from the configuration so
+// posting it again doesn't store it twice.
+func (add *Addon) HandleSaveConfig(c *fiber.Ctx) error {
+	if add.userDataKeys == nil && add.store == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "Configuration storage is not available on this server."})
+	}
+
+	userData := &UserData{}
+	if err := c.BodyParser(userData); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid configuration data."})
+	}
+
+	if err := add.validateUserData(c.UserContext(), userData); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	raw, err := json.Marshal(userData)
+	if err != nil {
+		return err
+	}
+	if len(raw) > maxConfigSize {
+		return c.Status(413).JSON(fiber.Map{"error": "Configuration is too large."})
+	}
+
+	if add.userDataKeys != nil {
+		token, err := add.userDataKeys.Seal(raw)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(fiber.Map{"token": token})
+	}
+
+	// the same configuration always gets the same token and record
+	key, err := add.configTokenKey()
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Failed to load the configuration key", "error", err)
+		return err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(raw)
+	token := hex.EncodeToString(mac.Sum(nil)[:16])
+	if err := add.store.Put(configsBucket, token, userData); err != nil {
+		slog.ErrorContext(c.UserContext(), "Failed to store configuration", "error", err)
+		return err
+	}
+
+	return c.JSON(fiber.Map{"token": token})
+}
+
+// configTokenKey returns the key deriving configuration tokens, created on
+// first use and kept in the store so tokens stay the same across restarts.
+func (add *Addon) configTokenKey() ([]byte, error) {
+	add.configKeyMu.Lock()
+	defer add.configKeyMu.Unlock()
+
+	if add.configKey != nil {
+		return add.configKey, nil
+	}
+
+	key := []byte{}
+	err := add.store.Get(secretsBucket, configKeyName, &key)
+	if errors.Is(err, store.ErrNotFound) {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		err = add.store.Put(secretsBucket, configKeyName, key)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	add.configKey = key
+	return key, nil
+}
+
+func (add *Addon) loadConfig(token string, userData *UserData) error {
+	if add.store == nil {
+		return errNoStore
+	}
+
+	return add.store.Get(configsBucket, token, userData)
+}
+
+func (add *Addon) openConfig(blob string, userData *UserData) error {
+	if add.userDataKeys == nil {
+		return errors.New("no userData keys are configured")
+	}
+
+	raw, err := add.userDataKeys.Open(blob)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(raw, userData)
+}
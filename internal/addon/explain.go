@@ -0,0 +1,174 @@
+package addon
+
+import (
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/dbytex91/streamx/internal/titleparser"
+)
+
+const (
+	candidateKept    = "kept"
+	candidateDropped = "dropped"
+	// candidatePending is left on candidates still in the pipe when it timed out
+	candidatePending = "pending"
+)
+
+// explanation collects every torrent found for a stream request with the
+// stage that dropped it, to tell why a search came back empty.
+type explanation struct {
+	mu         sync.Mutex
+	candidates []*explainCandidate
+}
+
+type explainCandidate struct {
+	Torrent   string                `json:"torrent"`
+	Indexer   string                `json:"indexer,omitempty"`
+	InfoHash  string                `json:"infoHash,omitempty"`
+	Size      uint                  `json:"size"`
+	Seeders   uint                  `json:"seeders"`
+	TitleInfo *titleparser.MetaInfo `json:"titleInfo"`
+	Status    string                `json:"status"`
+	Stage     string                `json:"stage,omitempty"`
+	Reason    string                `json:"reason,omitempty"`
+	Detail    string                `json:"detail,omitempty"`
+	Score     *qualityScore         `json:"score,omitempty"`
+	// Rank is the position among the kept torrents once sorted, from 1
+	Rank int `json:"rank,omitempty"`
+}
+
+type explainResponse struct {
+	Type       ContentType         `json:"type"`
+	ID         string              `json:"id"`
+	Error      string              `json:"error,omitempty"`
+	Summary    map[string]int      `json:"summary"`
+	Candidates []*explainCandidate `json:"candidates"`
+}
+
+func (e *explanation) add(r *streamRecord) *explainCandidate {
+	candidate := &explainCandidate{
+		Torrent:   r.Torrent.Title,
+		TitleInfo: r.TitleInfo,
+		Status:    candidatePending,
+	}
+	if r.Indexer != nil {
+		candidate.Indexer = r.Indexer.Name
+	}
+	candidate.update(r)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.candidates = append(e.candidates, candidate)
+	return candidate
+}
+
+func (c *explainCandidate) update(r *streamRecord) {
+	c.InfoHash = r.Torrent.InfoHash
+	c.Size = r.Torrent.Size
+	c.Seeders = r.Torrent.Seeders
+}
+
+// drop records why the pipe left the record out, it does nothing outside of
+// the explain endpoint.
+func (r *streamRecord) drop(stage, reason, detail string) {
+	if r.Explain == nil || r.Candidate == nil {
+		return
+	}
+
+	r.Explain.mu.Lock()
+	defer r.Explain.mu.Unlock()
+	r.Candidate.update(r)
+	r.Candidate.Status = candidateDropped
+	r.Candidate.Stage = stage
+	r.Candidate.Reason = reason
+	r.Candidate.Detail = detail
+}
+
+// HandleExplain runs the stream pipe of the request and lists every torrent
+// found with its parsed title, and either the stage and check that dropped it
+// or its score and rank among the streams returned. It's only routed under
+// userData, the base route would explain the operator's indexers to anyone.
+func (add *Addon) HandleExplain(c *fiber.Ctx) error {
+	userData, err := add.streamUserData(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	explain := &explanation{}
+	p := add.streamPipe(c, userData, explain)
+
+	records := []*streamRecord{}
+	var mu sync.Mutex
+	err = p.SinkWithTimeout(func(r *streamRecord) error {
+		mu.Lock()
+		defer mu.Unlock()
+		records = append(records, r)
+		return nil
+	}, searchTimeout(userData))
+
+	// records may still arrive when the pipe timed out
+	mu.Lock()
+	found := slices.Clone(records)
+	mu.Unlock()
+	found = sortRecords(c.UserContext(), found, userData)
+
+	response := explainResponse{
+		Type:    ContentType(c.Params("type")),
+		ID:      c.Params("id"),
+		Summary: map[string]int{},
+	}
+	if err != nil {
+		// errors may carry indexer URLs with their keys
+		slog.ErrorContext(c.UserContext(), "Error while explaining", "error", err)
+		response.Error = "The search failed, see the server logs."
+	}
+
+	explain.mu.Lock()
+	defer explain.mu.Unlock()
+
+	streams, requests := 0, 0
+	for i, r := range found {
+		candidate := r.Candidate
+		if candidate == nil {
+			continue
+		}
+
+		score := qualityScoreOf(r)
+		candidate.update(r)
+		candidate.Score = &score
+		candidate.Rank = i + 1
+
+		// same limits as HandleGetStreams
+		switch {
+		case r.Uncached && requests < maxStreamsResult:
+			requests++
+			candidate.Status = candidateKept
+			candidate.Detail = "offered as a download request"
+		case !r.Uncached && streams < maxStreamsResult:
+			streams++
+			candidate.Status = candidateKept
+		default:
+			candidate.Status = candidateDropped
+			candidate.Stage = "limit"
+			candidate.Reason = "max_streams"
+		}
+	}
+
+	for _, candidate := range explain.candidates {
+		switch candidate.Status {
+		case candidateDropped:
+			response.Summary[candidate.Stage+"/"+candidate.Reason]++
+		default:
+			response.Summary[candidate.Status]++
+		}
+	}
+	response.Candidates = explain.candidates
+
+	c.Set("Cache-Control", "no-store")
+	return c.JSON(response)
+}
@@ -0,0 +1,140 @@
+package addon
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// readinessCacheTTL keeps probes from hammering the dependencies
+	readinessCacheTTL = 30 * time.Second
+	readinessTimeout  = 5 * time.Second
+)
+
+type dependencyStatus struct {
+	Status    string    `json:"status"`
+	LatencyMS int64     `json:"latencyMs"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// readinessChecks caches the last check of the dependencies. Checks run one at
+// a time, probes arriving meanwhile wait for the running one.
+type readinessChecks struct {
+	mu       sync.Mutex
+	checked  time.Time
+	statuses map[string]dependencyStatus
+}
+
+// HandleHealth tells the process is up, it doesn't check any dependency.
+func (add *Addon) HandleHealth(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"status": "ok"})
+}
+
+// HandleReady checks the services configured through the environment:
+// Prowlarr, the Real-Debrid token and Cinemeta. It answers 503 when one of them
+// is unreachable, with the status of each in the body.
+func (add *Addon) HandleReady(c *fiber.Ctx) error {
+	statuses := add.readiness.check(c.UserContext(), add.dependencyChecks())
+
+	status := "ok"
+	for _, dependency := range statuses {
+		if dependency.Status != "ok" {
+			status = "unavailable"
+		}
+	}
+
+	c.Set("Cache-Control", "no-store")
+	if status != "ok" {
+		c.Status(fiber.StatusServiceUnavailable)
+	}
+
+	return c.JSON(fiber.Map{"status": status, "checks": statuses})
+}
+
+func (add *Addon) dependencyChecks() map[string]func(ctx context.Context) error {
+	checks := map[string]func(ctx context.Context) error{
+		"cinemeta": add.cinemetaClient.Ping,
+	}
+
+	if add.prowlarrClient != nil {
+		checks["prowlarr"] = func(ctx context.Context) error {
+			_, err := add.prowlarrClient.GetAllIndexers(ctx)
+			return err
+		}
+	}
+
+	if add.realDebridClient != nil {
+		checks["realdebrid"] = func(ctx context.Context) error {
+			_, err := add.realDebridClient.GetUser(ctx)
+			return err
+		}
+	}
+
+	return checks
+}
+
+func (r *readinessChecks) check(ctx context.Context, checks map[string]func(ctx context.Context) error) map[string]dependencyStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.checked) < readinessCacheTTL {
+		return r.statuses
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	statuses := make(map[string]dependencyStatus, len(checks))
+	statusesMu := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check func(ctx context.Context) error) {
+			defer wg.Done()
+
+			start := time.Now()
+			err := check(ctx)
+			status := dependencyStatus{
+				Status:    "ok",
+				LatencyMS: time.Since(start).Milliseconds(),
+				CheckedAt: start,
+			}
+			if err != nil {
+				// the probe is public, the details stay in the logs
+				slog.WarnContext(ctx, "Dependency check failed", "dependency", name, "error", err)
+				status.Status = "unavailable"
+				status.Error = failureCategory(err)
+			}
+
+			statusesMu.Lock()
+			statuses[name] = status
+			statusesMu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+
+	r.checked = time.Now()
+	r.statuses = statuses
+	return statuses
+}
+
+// failureCategory tells why a check failed without the URLs and answers
+// errors carry.
+func failureCategory(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.As(err, &netErr):
+		return "unreachable"
+	default:
+		return "error response"
+	}
+}
@@ -0,0 +1,127 @@
+package addon
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/dbytex91/streamx/internal/prowlarr"
+)
+
+// torrentLinkExpiry keeps the trackers of returned torrents for a week, so
+// download links stay valid while Stremio caches the stream list.
+const torrentLinkExpiry = 7 * 24 * 60 * 60
+
+var infoHashPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// HandleTorrentFile re-emits the .torrent of a torrent whose info dictionary
+// was seen before, for users who grab results into their own client.
+func (add *Addon) HandleTorrentFile(c *fiber.Ctx) error {
+	infoHash, ok := parseInfoHash(c.Params("infoHash"))
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid info hash."})
+	}
+
+	info, ok := add.metadataFetcher.Cached(infoHash)
+	if !ok {
+		return c.Status(404).JSON(fiber.Map{"error": "Torrent metadata is not known, use the magnet link."})
+	}
+
+	torrent, err := prowlarr.NewBytes(info.Bytes, add.cachedTrackers(infoHash), nil, "")
+	if err != nil {
+		return err
+	}
+
+	c.Set("Content-Type", "application/x-bittorrent")
+	c.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.torrent"`, strings.ReplaceAll(info.Name, `"`, "")))
+	c.Set("Cache-Control", "public, max-age=86400")
+	return c.Send(torrent)
+}
+
+// HandleMagnet redirects to the magnet link of the torrent.
+func (add *Addon) HandleMagnet(c *fiber.Ctx) error {
+	infoHash, ok := parseInfoHash(c.Params("infoHash"))
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid info hash."})
+	}
+
+	return c.Redirect(add.magnet(infoHash).String())
+}
+
+// magnet builds the magnet link of a torrent from what is known about it.
+func (add *Addon) magnet(infoHash [20]byte) *prowlarr.Magnet {
+	magnet := &prowlarr.Magnet{
+		InfoHash: infoHash,
+		Trackers: add.cachedTrackers(infoHash),
+	}
+	if info, ok := add.metadataFetcher.Cached(infoHash); ok {
+		magnet.Name = info.Name
+	}
+
+	return magnet
+}
+
+// rememberTorrent keeps what the download links need about a returned torrent.
+func (add *Addon) rememberTorrent(torrent *prowlarr.Torrent) {
+	if torrent.Info != nil {
+		add.metadataFetcher.Store(torrent.Info)
+	}
+
+	if len(torrent.Trackers) == 0 {
+		return
+	}
+
+	raw, err := json.Marshal(torrent.Trackers)
+	if err == nil {
+		err = add.cache.Set([]byte("trackers:"+torrent.InfoHash), raw, torrentLinkExpiry)
+	}
+	if err != nil {
+		slog.Warn("Couldn't cache trackers", "info_hash", torrent.InfoHash, "error", err)
+	}
+}
+
+func (add *Addon) cachedTrackers(infoHash [20]byte) [][]string {
+	raw, err := add.cache.Get([]byte("trackers:" + hex.EncodeToString(infoHash[:])))
+	if err != nil {
+		return nil
+	}
+
+	trackers := [][]string{}
+	if err := json.Unmarshal(raw, &trackers); err != nil {
+		return nil
+	}
+
+	return trackers
+}
+
+// downloadLinkStream returns a stream opening the torrent in an external
+// client, the .torrent when its metadata is known and the magnet otherwise.
+func (add *Addon) downloadLinkStream(r *streamRecord) StreamItem {
+	link := r.BaseURL + "/magnet/" + r.Torrent.InfoHash
+	if hash, ok := parseInfoHash(r.Torrent.InfoHash); ok {
+		if _, ok := add.metadataFetcher.Cached(hash); ok {
+			link = r.BaseURL + "/torrent/" + r.Torrent.InfoHash + ".torrent"
+		}
+	}
+
+	return StreamItem{
+		Name:        "StreamX\n⬇️ Download",
+		Title:       r.Torrent.Title + "\n📥 Open in torrent client",
+		ExternalURL: link,
+	}
+}
+
+func parseInfoHash(raw string) ([20]byte, bool) {
+	raw = strings.ToLower(raw)
+	if !infoHashPattern.MatchString(raw) {
+		return [20]byte{}, false
+	}
+
+	hash, _ := hex.DecodeString(raw)
+	return [20]byte(hash), true
+}
@@ -1,8 +1,15 @@
 package addon
 
 import (
+	"crypto/rand"
+	"strings"
+
 	"github.com/dbytex91/streamx/internal/debrid/realdebrid"
+	"github.com/dbytex91/streamx/internal/downloader"
 	"github.com/dbytex91/streamx/internal/prowlarr"
+	"github.com/dbytex91/streamx/internal/seal"
+	"github.com/dbytex91/streamx/internal/store"
+	"github.com/dbytex91/streamx/internal/tmdb"
 )
 
 func WithID(id string) Option {
@@ -32,6 +39,75 @@ func WithRealDebrid(apiKey string) Option {
 	}
 }
 
+// WithTMDB makes TMDB the first metadata provider, Cinemeta stays as fallback.
+func WithTMDB(apiKey string) Option {
+	return func(a *Addon) {
+		a.metadataProviders = append(a.metadataProviders, tmdb.New(apiKey))
+	}
+}
+
+// WithPublicTrackers replaces the public trackers users can add to native streams.
+func WithPublicTrackers(trackers []string) Option {
+	return func(a *Addon) {
+		a.publicTrackers = trackers
+	}
+}
+
+// WithDownloader lets users whose configuration holds one of accessKeys send
+// results to the download client of the server. Send links are signed with
+// secret, a random one is used when it's empty, which invalidates the links on
+// restart.
+func WithDownloader(client downloader.Client, secret string, accessKeys []string) Option {
+	return func(a *Addon) {
+		a.downloadClient = client
+		a.downloaderKeys = accessKeys
+		a.downloadSecret = []byte(secret)
+		if secret == "" {
+			a.downloadSecret = make([]byte, 32)
+			_, _ = rand.Read(a.downloadSecret)
+		}
+	}
+}
+
+// WithStatusVideos sets where the videos played while a requested Real-Debrid
+// download isn't ready are hosted, replacing the bundled ones.
+func WithStatusVideos(baseURL string) Option {
+	return func(a *Addon) {
+		a.statusVideosURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithStore keeps configurations, linked Real-Debrid accounts and torrent
+// info dictionaries in s.
+func WithStore(s *store.Store) Option {
+	return func(a *Addon) {
+		a.store = s
+	}
+}
+
+// WithUserDataKeys makes the configure page produce sealed configurations
+// instead of storing them on the server.
+func WithUserDataKeys(keys *seal.Keyring) Option {
+	return func(a *Addon) {
+		a.userDataKeys = keys
+	}
+}
+
+// WithUnsealedUserData keeps accepting configurations inlined in URLs once
+// userData keys are set, for installs made before sealing was enabled.
+func WithUnsealedUserData(allowed bool) Option {
+	return func(a *Addon) {
+		a.allowUnsealed = allowed
+	}
+}
+
+// WithPrivateProwlarrURLs lets users enter Prowlarr URLs on loopback,
+// private and link-local addresses, e.g. on a home network.
+func WithPrivateProwlarrURLs(allowed bool) Option {
+	return func(a *Addon) {
+		a.allowPrivateURLs = allowed
+	}
+}
 
 func WithVersion(version string) Option {
 	return func(a *Addon) {
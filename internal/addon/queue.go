@@ -0,0 +1,155 @@
+package addon
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/dbytex91/streamx/internal/debrid/realdebrid"
+)
+
+const (
+	downloadPollInterval = 30 * time.Second
+	// requestedDownloadExpiry is how long requested downloads are tracked,
+	// finished ones stay cached on Real-Debrid after that.
+	requestedDownloadExpiry = 24 * time.Hour
+)
+
+// downloadQueue tracks the downloads users requested on Real-Debrid until
+// they finish, so the next stream request lists them as cached.
+type downloadQueue struct {
+	mu        sync.Mutex
+	downloads map[string]*requestedDownload
+	polling   sync.Once
+}
+
+type requestedDownload struct {
+	client    *realdebrid.RealDebrid
+	torrentID string
+	infoHash  string
+	status    string
+	progress  float64
+	files     []*realdebrid.File
+	added     time.Time
+}
+
+func newDownloadQueue() *downloadQueue {
+	return &downloadQueue{
+		downloads: map[string]*requestedDownload{},
+	}
+}
+
+// request adds the magnet on Real-Debrid unless it was requested before and
+// returns the state of the download. The download is tracked before the
+// magnet is added, so requests arriving meanwhile don't add it again.
+func (q *downloadQueue) request(ctx context.Context, account string, client *realdebrid.RealDebrid, infoHash, magnetURI string) (requestedDownload, error) {
+	q.polling.Do(func() {
+		go q.poll(downloadPollInterval)
+	})
+
+	q.mu.Lock()
+	download, ok := q.downloads[account+infoHash]
+	if ok {
+		// the access token of linked accounts changes when it's refreshed
+		download.client = client
+	} else {
+		download = &requestedDownload{
+			client:   client,
+			infoHash: infoHash,
+			status:   "queued",
+			added:    time.Now(),
+		}
+		q.downloads[account+infoHash] = download
+	}
+	q.mu.Unlock()
+	if ok {
+		return q.snapshot(download), nil
+	}
+
+	torrentID, err := client.RequestDownload(ctx, magnetURI)
+	if err != nil {
+		q.mu.Lock()
+		delete(q.downloads, account+infoHash)
+		q.mu.Unlock()
+		return requestedDownload{}, err
+	}
+
+	q.mu.Lock()
+	download.torrentID = torrentID
+	q.mu.Unlock()
+	q.update(ctx, download)
+
+	slog.InfoContext(ctx, "Requested download on Real-Debrid", "info_hash", infoHash)
+	return q.snapshot(download), nil
+}
+
+// lookup returns the state of a requested download.
+func (q *downloadQueue) lookup(account, infoHash string) (requestedDownload, bool) {
+	q.mu.Lock()
+	download, ok := q.downloads[account+infoHash]
+	q.mu.Unlock()
+	if !ok {
+		return requestedDownload{}, false
+	}
+
+	return q.snapshot(download), true
+}
+
+func (q *downloadQueue) poll(interval time.Duration) {
+	for range time.Tick(interval) {
+		q.mu.Lock()
+		pending := make([]*requestedDownload, 0, len(q.downloads))
+		for key, download := range q.downloads {
+			if time.Since(download.added) > requestedDownloadExpiry {
+				delete(q.downloads, key)
+				continue
+			}
+
+			// downloads still being added have no torrent yet
+			if download.torrentID != "" && download.files == nil && download.status != "failed" {
+				pending = append(pending, download)
+			}
+		}
+		q.mu.Unlock()
+
+		for _, download := range pending {
+			q.update(context.Background(), download)
+		}
+	}
+}
+
+func (q *downloadQueue) update(ctx context.Context, download *requestedDownload) {
+	q.mu.Lock()
+	client, torrentID := download.client, download.torrentID
+	q.mu.Unlock()
+
+	torrent, err := client.GetTorrent(ctx, torrentID)
+	if err != nil {
+		slog.WarnContext(ctx, "Couldn't check download on Real-Debrid", "info_hash", download.infoHash, "error", err)
+		return
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	download.status = torrent.Status
+	download.progress = torrent.Progress
+	switch {
+	case torrent.Downloaded():
+		download.files = torrent.SelectedFiles()
+		slog.InfoContext(ctx, "Download finished on Real-Debrid", "info_hash", download.infoHash)
+	case torrent.Failed():
+		download.status = "failed"
+		slog.InfoContext(ctx, "Download failed on Real-Debrid", "info_hash", download.infoHash, "status", torrent.Status)
+	}
+}
+
+func (q *downloadQueue) snapshot(download *requestedDownload) requestedDownload {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return *download
+}
+
+func (d requestedDownload) ready() bool {
+	return d.files != nil
+}
@@ -0,0 +1,183 @@
+package addon
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/dbytex91/streamx/internal/debrid/realdebrid"
+)
+
+const (
+	realDebridLinksBucket = "realdebrid-links"
+	// tokenRefreshMargin refreshes access tokens a bit before they expire
+	tokenRefreshMargin = time.Minute
+)
+
+// realDebridLink is a Real-Debrid account linked with the device flow. Users
+// only carry its opaque id, the tokens never leave the server.
+type realDebridLink struct {
+	ClientID     string    `json:"clientId"`
+	ClientSecret string    `json:"clientSecret"`
+	AccessToken  string    `json:"accessToken"`
+	RefreshToken string    `json:"refreshToken"`
+	Expiry       time.Time `json:"expiry"`
+}
+
+var errNoStore = errors.New("no store is configured")
+
+// linkLocks holds a lock per link id, so a slow refresh only holds up the
+// requests of its own account.
+type linkLocks struct {
+	mu    sync.Mutex
+	locks map[string]*linkLock
+}
+
+type linkLock struct {
+	sync.Mutex
+	waiters int
+}
+
+// lock locks the link and returns the function unlocking it, the lock is
+// dropped once nobody waits for it.
+func (l *linkLocks) lock(linkID string) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = map[string]*linkLock{}
+	}
+	lock, ok := l.locks[linkID]
+	if !ok {
+		lock = &linkLock{}
+		l.locks[linkID] = lock
+	}
+	lock.waiters++
+	l.mu.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		lock.waiters--
+		if lock.waiters == 0 {
+			delete(l.locks, linkID)
+		}
+	}
+}
+
+type linkRequest struct {
+	DeviceCode string `json:"deviceCode"`
+}
+
+// HandleRealDebridDevice starts linking a Real-Debrid account, the configure
+// page shows the user code to enter on Real-Debrid.
+func (add *Addon) HandleRealDebridDevice(c *fiber.Ctx) error {
+	if add.store == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "Account linking is not available on this server."})
+	}
+
+	code, err := add.realDebridOAuth.DeviceCode()
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Couldn't get a Real-Debrid device code", "error", err)
+		return c.Status(502).JSON(fiber.Map{"error": "Real-Debrid is not reachable."})
+	}
+
+	return c.JSON(fiber.Map{
+		"deviceCode":      code.DeviceCode,
+		"userCode":        code.UserCode,
+		"verificationUrl": code.VerificationURL,
+		"interval":        code.Interval,
+		"expiresIn":       code.ExpiresIn,
+	})
+}
+
+// HandleRealDebridLink is polled by the configure page until the user entered
+// the code, it then stores the tokens and returns the id of the link.
+func (add *Addon) HandleRealDebridLink(c *fiber.Ctx) error {
+	if add.store == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "Account linking is not available on this server."})
+	}
+
+	req := linkRequest{}
+	if err := c.BodyParser(&req); err != nil || req.DeviceCode == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Missing device code."})
+	}
+
+	creds, err := add.realDebridOAuth.Credentials(req.DeviceCode)
+	if errors.Is(err, realdebrid.ErrAuthorizationPending) {
+		return c.Status(202).JSON(fiber.Map{"status": "pending"})
+	}
+	if errors.Is(err, realdebrid.ErrDeviceCodeExpired) {
+		return c.Status(410).JSON(fiber.Map{"status": "expired", "error": "The code expired."})
+	}
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Couldn't get Real-Debrid credentials", "error", err)
+		return c.Status(502).JSON(fiber.Map{"error": "Real-Debrid is not reachable."})
+	}
+
+	token, err := add.realDebridOAuth.Token(creds, req.DeviceCode)
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Couldn't get a Real-Debrid token", "error", err)
+		return c.Status(502).JSON(fiber.Map{"error": "Real-Debrid refused the link."})
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+
+	link := realDebridLink{
+		ClientID:     creds.ClientID,
+		ClientSecret: creds.ClientSecret,
+		AccessToken:  token.AccessToken,
+		RefreshToken: token.RefreshToken,
+		Expiry:       time.Now().Add(time.Duration(token.ExpiresIn) * time.Second),
+	}
+	if err := add.store.Put(realDebridLinksBucket, hex.EncodeToString(id), link); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{"status": "linked", "link": hex.EncodeToString(id)})
+}
+
+// realDebridAccessToken returns a valid access token of the linked account,
+// refreshing it when it expired.
+func (add *Addon) realDebridAccessToken(linkID string) (string, error) {
+	if add.store == nil {
+		return "", errNoStore
+	}
+
+	// refresh tokens are single use, refresh each link once at a time
+	unlock := add.linkLocks.lock(linkID)
+	defer unlock()
+
+	link := realDebridLink{}
+	if err := add.store.Get(realDebridLinksBucket, linkID, &link); err != nil {
+		return "", err
+	}
+
+	if time.Until(link.Expiry) > tokenRefreshMargin {
+		return link.AccessToken, nil
+	}
+
+	creds := &realdebrid.Credentials{ClientID: link.ClientID, ClientSecret: link.ClientSecret}
+	token, err := add.realDebridOAuth.Refresh(creds, link.RefreshToken)
+	if err != nil {
+		return "", err
+	}
+
+	link.AccessToken = token.AccessToken
+	link.RefreshToken = token.RefreshToken
+	link.Expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	if err := add.store.Put(realDebridLinksBucket, linkID, link); err != nil {
+		return "", err
+	}
+
+	return link.AccessToken, nil
+}
@@ -0,0 +1,122 @@
+package addon
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/dbytex91/streamx/internal/debrid/realdebrid"
+)
+
+// Status videos played while a requested download isn't ready, relative to
+// the URL set with WithStatusVideos or to the ones bundled under /status.
+const (
+	downloadingVideo = "/downloading.mp4"
+	failedVideo      = "/failed.mp4"
+	readyVideo       = "/ready.mp4"
+)
+
+// HandleRequestDownload starts downloading an uncached torrent on
+// Real-Debrid and plays a status video until it is ready, the next stream
+// request then lists it as cached.
+func (add *Addon) HandleRequestDownload(c *fiber.Ctx) error {
+	infoHash, ok := parseInfoHash(c.Params("infoHash"))
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid info hash."})
+	}
+
+	userData := NewUserDataWithDefaults()
+	if c.Params("userData") != "" {
+		var err error
+		userData, err = parseUserData(add, c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "Invalid configuration data.",
+			})
+		}
+	}
+
+	apiKey := add.realDebridKey(userData)
+	if apiKey == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Requesting downloads requires Real Debrid configuration.",
+		})
+	}
+
+	c.Set("Cache-control", "no-store")
+	hash := fmt.Sprintf("%x", infoHash)
+	client := realdebrid.New(apiKey, getIPAddress(c))
+	download, err := add.downloads.request(c.UserContext(), add.realDebridAccount(userData), client, hash, add.magnet(infoHash).String())
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Couldn't request download", "info_hash", hash, "error", err)
+		return add.statusVideo(c, failedVideo)
+	}
+
+	switch {
+	case download.ready():
+		return add.statusVideo(c, readyVideo)
+	case download.status == "failed":
+		return add.statusVideo(c, failedVideo)
+	default:
+		return add.statusVideo(c, downloadingVideo)
+	}
+}
+
+// statusVideo redirects to a status video, the bundled ones unless others
+// are configured.
+func (add *Addon) statusVideo(c *fiber.Ctx, video string) error {
+	if add.statusVideosURL == "" {
+		return c.Redirect(c.BaseURL() + "/status" + video)
+	}
+
+	return c.Redirect(add.statusVideosURL + video)
+}
+
+// requestDownloadStream returns a stream requesting the download of an
+// uncached torrent, showing the progress once it was requested.
+func (add *Addon) requestDownloadStream(r *streamRecord, streamURL string) StreamItem {
+	status := "⏬ Request download on Real-Debrid"
+	if download, ok := add.downloads.lookup(add.realDebridAccount(r.UserData), r.Torrent.InfoHash); ok {
+		status = fmt.Sprintf("⏳ Downloading on Real-Debrid %.0f%%", download.progress)
+		if download.status == "failed" {
+			status = "❌ Real-Debrid download failed"
+		}
+	}
+
+	return StreamItem{
+		Name:  "StreamX\n⏳ RD",
+		Title: r.Torrent.Title + "\n" + status,
+		URL:   streamURL,
+	}
+}
+
+// realDebridKey returns the Real-Debrid API key of the user, the access token
+// of their linked account, or the key of the server.
+func (add *Addon) realDebridKey(userData *UserData) string {
+	if userData.RDAPIKey != "" {
+		return userData.RDAPIKey
+	}
+
+	if userData.RDLink != "" {
+		token, err := add.realDebridAccessToken(userData.RDLink)
+		if err != nil {
+			slog.Error("Couldn't get the token of a linked Real-Debrid account", "error", err)
+			return ""
+		}
+
+		return token
+	}
+
+	return add.realDebridAPIKey
+}
+
+// realDebridAccount identifies the Real-Debrid account of the user, it stays
+// the same when the access token of a linked account is refreshed.
+func (add *Addon) realDebridAccount(userData *UserData) string {
+	if userData.RDAPIKey == "" && userData.RDLink != "" {
+		return "link:" + userData.RDLink
+	}
+
+	return add.realDebridKey(userData)
+}
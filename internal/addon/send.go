@@ -0,0 +1,113 @@
+package addon
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"log/slog"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// sendPage is the short page shown in the browser Stremio opens for the
+// "Download to server" stream.
+const sendPage = `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1"><title>StreamX</title></head>
+<body style="font-family: sans-serif; text-align: center; margin-top: 20vh;">
+<h2>%s</h2>
+<p>%s</p>
+</body>
+</html>`
+
+// HandleSendToServer adds the torrent to the download client of the server.
+// Links are signed with the access key of the configuration they were listed
+// for, so only torrents returned by StreamX to users the operator gave a key
+// can be added, and removing a key revokes its links.
+func (add *Addon) HandleSendToServer(c *fiber.Ctx) error {
+	if add.downloadClient == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "No download client is configured."})
+	}
+
+	infoHash, ok := parseInfoHash(c.Params("infoHash"))
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid info hash."})
+	}
+
+	signature, err := hex.DecodeString(c.Params("signature"))
+	if err != nil || !add.validSendSignature(signature, infoHash) {
+		return c.Status(403).JSON(fiber.Map{"error": "Invalid signature."})
+	}
+
+	magnet := add.magnet(infoHash)
+	c.Set("Content-Type", "text/html; charset=utf-8")
+	if err := add.downloadClient.AddMagnet(magnet.String()); err != nil {
+		slog.ErrorContext(c.UserContext(), "Couldn't send torrent to the download client", "info_hash", hex.EncodeToString(infoHash[:]), "client", add.downloadClient.Name(), "error", err)
+		return c.Status(502).SendString(fmt.Sprintf(sendPage, "Download failed",
+			html.EscapeString(add.downloadClient.Name()+" did not accept the torrent.")))
+	}
+
+	name := magnet.Name
+	if name == "" {
+		name = hex.EncodeToString(infoHash[:])
+	}
+	slog.InfoContext(c.UserContext(), "Sent torrent to the download client", "torrent", name, "client", add.downloadClient.Name())
+
+	return c.SendString(fmt.Sprintf(sendPage, "Download started",
+		html.EscapeString(name+" was added to "+add.downloadClient.Name()+".")))
+}
+
+// sendToServerStream returns a stream adding the torrent to the download
+// client of the server when opened. It's only offered to configurations
+// holding one of the access keys of the operator.
+func (add *Addon) sendToServerStream(r *streamRecord) (StreamItem, bool) {
+	accessKey := r.UserData.DownloaderKey
+	if !add.isDownloaderKey(accessKey) {
+		return StreamItem{}, false
+	}
+
+	infoHash, ok := parseInfoHash(r.Torrent.InfoHash)
+	if !ok {
+		return StreamItem{}, false
+	}
+
+	return StreamItem{
+		Name:        "StreamX\n🏠 Server",
+		Title:       r.Torrent.Title + "\n📥 Download to server",
+		ExternalURL: r.BaseURL + "/send/" + r.Torrent.InfoHash + "/" + hex.EncodeToString(add.signInfoHash(accessKey, infoHash)),
+	}, true
+}
+
+func (add *Addon) isDownloaderKey(accessKey string) bool {
+	if accessKey == "" {
+		return false
+	}
+
+	for _, key := range add.downloaderKeys {
+		if hmac.Equal([]byte(accessKey), []byte(key)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (add *Addon) validSendSignature(signature []byte, infoHash [20]byte) bool {
+	for _, key := range add.downloaderKeys {
+		if hmac.Equal(signature, add.signInfoHash(key, infoHash)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (add *Addon) signInfoHash(accessKey string, infoHash [20]byte) []byte {
+	mac := hmac.New(sha256.New, add.downloadSecret)
+	mac.Write([]byte(accessKey))
+	mac.Write([]byte{0})
+	mac.Write(infoHash[:])
+	return mac.Sum(nil)
+}
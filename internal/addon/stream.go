@@ -8,7 +8,8 @@ type StreamItem struct {
 	Name          string               `json:"name,omitempty"`
 	Description   string               `json:"description,omitempty"`
 	Title         string               `json:"title,omitempty"`
-	FileIndex     uint8                `json:"fileIdx,omitempty"`
+	FileIndex     *int                 `json:"fileIdx,omitempty"`
+	Sources       []string             `json:"sources,omitempty"`
 	BehaviorHints *StreamBehaviorHints `json:"behaviorHints,omitempty"`
 }
 
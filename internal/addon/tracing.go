@@ -0,0 +1,152 @@
+package addon
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/dbytex91/streamx/internal/tracing"
+)
+
+// stageSpans keeps one span per pipe stage of a request. A stage span starts
+// with the first record reaching the stage and ends with its last one, the
+// records add an event each instead of a span of their own.
+type stageSpans struct {
+	mu     sync.Mutex
+	stages map[string]*stageSpan
+	ended  bool
+}
+
+type stageSpan struct {
+	ctx     context.Context
+	span    trace.Span
+	records int
+	err     error
+	last    time.Time
+}
+
+func newStageSpans() *stageSpans {
+	return &stageSpans{stages: map[string]*stageSpan{}}
+}
+
+// start returns the context of the stage span, outbound calls made with it
+// nest under the stage. The first record's ctx is the parent of the span, ctx
+// is returned as is without spans or once the pipe is done.
+func (s *stageSpans) start(ctx context.Context, name string) context.Context {
+	if s == nil {
+		return ctx
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ended {
+		return ctx
+	}
+
+	stage, ok := s.stages[name]
+	if !ok {
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		ctx, span := tracing.Start(ctx, "stage "+name)
+		stage = &stageSpan{ctx: ctx, span: span}
+		s.stages[name] = stage
+	}
+
+	return stage.ctx
+}
+
+// finish adds the event of a record done with the stage. The first error
+// marks the whole stage failed.
+func (s *stageSpans) finish(name string, err error, attrs ...attribute.KeyValue) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stage, ok := s.stages[name]
+	if !ok || s.ended {
+		return
+	}
+
+	stage.records++
+	stage.last = time.Now()
+	if err != nil {
+		tracing.RecordError(stage.span, err, attrs...)
+		if stage.err == nil {
+			stage.err = err
+		}
+		return
+	}
+	stage.span.AddEvent("record", trace.WithAttributes(attrs...))
+}
+
+// end ends the stage spans once the pipe is done, at the time their last
+// record finished.
+func (s *stageSpans) end() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ended = true
+	for _, stage := range s.stages {
+		stage.span.SetAttributes(attribute.Int("streamx.records", stage.records))
+		if stage.err != nil {
+			tracing.End(stage.span, stage.err)
+			continue
+		}
+		stage.span.End(trace.WithTimestamp(stage.last))
+	}
+}
+
+// traced runs a pipe stage under the stage span of the request. The record
+// carries the span while the stage runs so outbound calls nest under it,
+// records passed on get the context they came in with back.
+func traced(name string, fn func(r *streamRecord) ([]*streamRecord, error)) func(r *streamRecord) ([]*streamRecord, error) {
+	return func(r *streamRecord) ([]*streamRecord, error) {
+		parent := r.Context
+		attrs := r.spanAttributes()
+		r.Context = r.Spans.start(parent, name)
+		outs, err := fn(r)
+		r.Context = parent
+		for _, out := range outs {
+			if out != nil {
+				out.Context = parent
+			}
+		}
+
+		r.Spans.finish(name, err, append(attrs, attribute.Int("streamx.outputs", len(outs)))...)
+		return outs, err
+	}
+}
+
+func tracedMap(name string, fn func(r *streamRecord) (*streamRecord, error)) func(r *streamRecord) (*streamRecord, error) {
+	stage := traced(name, func(r *streamRecord) ([]*streamRecord, error) {
+		out, err := fn(r)
+		return []*streamRecord{out}, err
+	})
+
+	return func(r *streamRecord) (*streamRecord, error) {
+		outs, err := stage(r)
+		return outs[0], err
+	}
+}
+
+// spanAttributes describes the record without user data, torrents and
+// indexers are the same for everyone.
+func (r *streamRecord) spanAttributes() []attribute.KeyValue {
+	attrs := []attribute.KeyValue{attribute.String("streamx.content_type", string(r.ContentType))}
+	if r.Indexer != nil {
+		attrs = append(attrs, attribute.String("streamx.indexer", r.Indexer.Name))
+	}
+	if r.Torrent != nil && r.Torrent.InfoHash != "" {
+		attrs = append(attrs, attribute.String("streamx.info_hash", r.Torrent.InfoHash))
+	}
+
+	return attrs
+}
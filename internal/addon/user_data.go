@@ -1,7 +1,9 @@
 package addon
 
 type UserData struct {
-	RDAPIKey          string `json:"rd"`
+	RDAPIKey string `json:"rd"`
+	// RDLink is the id of a Real-Debrid account linked with the device flow
+	RDLink            string `json:"rdLink"`
 	ProwlarrURL       string `json:"pUrl"`
 	ProwlarrAPIKey    string `json:"pKey"`
 	MinResolution     string `json:"minRes"`
@@ -12,26 +14,34 @@ type UserData struct {
 	ExcludedQualities string `json:"excludedQualities"`
 	SearchTimeout     string `json:"searchTimeout"`
 	SortMethod        string `json:"sortMethod"`
+	PreferredChannels string `json:"prefChannels"`
+	ExcludedAudio     string `json:"excludedAudio"`
+	PublicTrackers    string `json:"publicTrackers"`
+	ShowDownloadLinks string `json:"downloadLinks"`
+	SendToServer      string `json:"sendToServer"`
+	// DownloaderKey is the access key the operator gave to send results to the server
+	DownloaderKey   string `json:"sendKey"`
+	RequestDownload string `json:"requestDownload"`
 }
 
 // NewUserDataWithDefaults creates UserData with sensible defaults
 func NewUserDataWithDefaults() *UserData {
 	return &UserData{
-		MinResolution:     "720",      // 720p minimum for good quality
-		MaxResolution:     "2160",     // 4K maximum
-		MinSize:           "0.5",      // 0.5GB minimum to avoid low quality
-		MaxSize:           "25",       // 25GB maximum to avoid oversized files
-		MinSeeders:        "10",        // 5 seeders minimum for reliability
+		MinResolution:     "720",                                                 // 720p minimum for good quality
+		MaxResolution:     "2160",                                                // 4K maximum
+		MinSize:           "0.5",                                                 // 0.5GB minimum to avoid low quality
+		MaxSize:           "25",                                                  // 25GB maximum to avoid oversized files
+		MinSeeders:        "10",                                                  // 5 seeders minimum for reliability
 		ExcludedQualities: "cam,camrip,telesync,tsrip,hdcam,tc,ppvrip,r5,vhsscr", // Exclude poor quality
-		SearchTimeout:     "60",       // 45 seconds for good balance
-		SortMethod:        "quality",  // Quality score method
+		SearchTimeout:     "60",                                                  // 45 seconds for good balance
+		SortMethod:        "quality",                                             // Quality score method
 	}
 }
 
 // ApplyDefaults fills in any missing values with defaults
 func (u *UserData) ApplyDefaults() {
 	defaults := NewUserDataWithDefaults()
-	
+
 	if u.MinResolution == "" {
 		u.MinResolution = defaults.MinResolution
 	}
@@ -0,0 +1,212 @@
+package addon
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/netip"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/dbytex91/streamx/internal/debrid/realdebrid"
+)
+
+const (
+	validateTimeout = 10 * time.Second
+	// validateTitleID is a title every metadata provider knows
+	validateTitleID = "tt0111161"
+)
+
+// serviceReport is the result of trying one service of a configuration.
+// Status is ok, error or skipped when the service isn't configured.
+type serviceReport struct {
+	Status   string          `json:"status"`
+	Error    string          `json:"error,omitempty"`
+	Indexers []indexerReport `json:"indexers,omitempty"`
+	Account  *accountReport  `json:"account,omitempty"`
+	Title    string          `json:"title,omitempty"`
+}
+
+type indexerReport struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+}
+
+type accountReport struct {
+	Username   string `json:"username"`
+	Premium    bool   `json:"premium"`
+	Expiration string `json:"expiration,omitempty"`
+}
+
+// HandleValidateConfig tries the services of the configuration posted by the
+// configure page, so a wrong Prowlarr URL or Real-Debrid token shows up before
+// the addon is installed rather than as an empty stream list.
+func (add *Addon) HandleValidateConfig(c *fiber.Ctx) error {
+	userData := &UserData{}
+	if err := c.BodyParser(userData); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid configuration data."})
+	}
+
+	if err := add.validateUserData(c.UserContext(), userData); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), validateTimeout)
+	defer cancel()
+
+	checks := map[string]func(ctx context.Context) serviceReport{
+		"prowlarr":   func(ctx context.Context) serviceReport { return add.validateProwlarr(ctx, userData) },
+		"realdebrid": func(ctx context.Context) serviceReport { return add.validateRealDebrid(ctx, userData) },
+		"metadata":   add.validateMetadata,
+	}
+
+	reports := make(map[string]serviceReport, len(checks))
+	mu := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check func(ctx context.Context) serviceReport) {
+			defer wg.Done()
+			report := check(ctx)
+
+			mu.Lock()
+			reports[name] = report
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+
+	valid := true
+	for _, report := range reports {
+		if report.Status == "error" {
+			valid = false
+		}
+	}
+
+	return c.JSON(fiber.Map{"valid": valid, "services": reports})
+}
+
+func (add *Addon) validateProwlarr(ctx context.Context, userData *UserData) serviceReport {
+	client := add.prowlarrFor(userData)
+	if client == nil {
+		return serviceReport{Status: "skipped"}
+	}
+
+	indexers, err := client.GetAllIndexers(ctx)
+	if err != nil {
+		return failedReport(ctx, "prowlarr", err, "Couldn't list the Prowlarr indexers, check the URL and API key.")
+	}
+
+	report := serviceReport{Status: "ok", Indexers: make([]indexerReport, 0, len(indexers))}
+	enabled := 0
+	for _, indexer := range indexers {
+		report.Indexers = append(report.Indexers, indexerReport{Name: indexer.Name, Enabled: indexer.Enable})
+		if indexer.Enable {
+			enabled++
+		}
+	}
+
+	if enabled == 0 {
+		report.Status = "error"
+		report.Error = "No indexer is enabled in Prowlarr."
+	}
+
+	return report
+}
+
+func (add *Addon) validateRealDebrid(ctx context.Context, userData *UserData) serviceReport {
+	if userData.RDAPIKey == "" && userData.RDLink == "" && add.realDebridAPIKey == "" {
+		return serviceReport{Status: "skipped"}
+	}
+
+	apiKey := add.realDebridKey(userData)
+	if apiKey == "" {
+		return serviceReport{Status: "error", Error: "The linked Real-Debrid account is no longer available, link it again."}
+	}
+
+	user, err := realdebrid.New(apiKey, "").GetUser(ctx)
+	if err != nil {
+		return failedReport(ctx, "realdebrid", err, "Couldn't load the Real-Debrid account, check the API key.")
+	}
+
+	report := serviceReport{
+		Status: "ok",
+		Account: &accountReport{
+			Username:   user.Username,
+			Premium:    user.Type == "premium",
+			Expiration: user.Expiration,
+		},
+	}
+	if !report.Account.Premium {
+		// only premium accounts can unrestrict links
+		report.Status = "error"
+		report.Error = "The Real-Debrid account is not premium."
+	}
+
+	return report
+}
+
+func (add *Addon) validateMetadata(ctx context.Context) serviceReport {
+	metaInfo, err := add.metadataProviders.GetMovieById(ctx, validateTitleID)
+	if err != nil {
+		return failedReport(ctx, "metadata", err, "The metadata provider is unavailable.")
+	}
+
+	return serviceReport{Status: "ok", Title: metaInfo.Name}
+}
+
+// failedReport logs the error and reports a generic message, errors carry
+// URLs and answers of hosts the configuration points to, which would make the
+// endpoint a probe of the server's network.
+func failedReport(ctx context.Context, service string, err error, message string) serviceReport {
+	slog.WarnContext(ctx, "Configuration test failed", "service", service, "error", err)
+	if errors.Is(err, context.DeadlineExceeded) {
+		message = "The service didn't answer in time."
+	}
+
+	return serviceReport{Status: "error", Error: message}
+}
+
+var errPrivateProwlarrURL = errors.New("prowlarr URLs on private networks are not allowed on this server")
+
+// checkProwlarrURL refuses Prowlarr URLs of users pointing at the server's own
+// network, the server would otherwise query internal hosts for anyone.
+// Hostnames are resolved and every address has to be public.
+func (add *Addon) checkProwlarrURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return errors.New("prowlarr URL must be an http or https URL")
+	}
+
+	if add.allowPrivateURLs {
+		return nil
+	}
+
+	addrs := []netip.Addr{}
+	if addr, err := netip.ParseAddr(u.Hostname()); err == nil {
+		addrs = append(addrs, addr)
+	} else {
+		ctx, cancel := context.WithTimeout(ctx, validateTimeout)
+		defer cancel()
+
+		ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+		if err != nil {
+			return errors.New("prowlarr URL host couldn't be resolved")
+		}
+		addrs = append(addrs, ips...)
+	}
+
+	for _, addr := range addrs {
+		addr = addr.Unmap()
+		if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+			addr.IsUnspecified() || addr.IsMulticast() || addr.IsInterfaceLocalMulticast() {
+			return errPrivateProwlarrURL
+		}
+	}
+
+	return nil
+}
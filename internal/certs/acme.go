@@ -0,0 +1,446 @@
+package certs
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/acme"
+)
+
+const (
+	ChallengeHTTP01    = "http-01"
+	ChallengeTLSALPN01 = "tls-alpn-01"
+	ChallengeDNS01     = "dns-01"
+
+	defaultRenewBefore = 30 * 24 * time.Hour
+	// checkInterval is how often the certificate expiry is checked, retryInterval
+	// how long to wait after a failed attempt so CA rate limits aren't hit.
+	checkInterval  = 12 * time.Hour
+	retryInterval  = time.Hour
+	obtainTimeout  = 10 * time.Minute
+	accountKeyFile = "account.key"
+)
+
+var errNoCertificate = errors.New("certs: no certificate obtained yet")
+
+// Config configures a Manager.
+type Config struct {
+	// Domains are the names of the certificate, wildcards need DNS-01.
+	Domains []string
+	Email   string
+	// DirectoryURL is the ACME directory, e.g. acme.LetsEncryptURL.
+	DirectoryURL string
+	// CacheDir keeps the account key and the certificate across restarts.
+	CacheDir string
+	// Challenge is ChallengeHTTP01, ChallengeTLSALPN01 or ChallengeDNS01.
+	Challenge   string
+	DNSProvider DNSProvider
+	// DNSPropagationTimeout bounds the wait for the TXT record to be visible,
+	// the CA is asked to validate anyway once it's over. 0 doesn't wait.
+	DNSPropagationTimeout time.Duration
+	// RenewBefore is how long before expiry the certificate is renewed.
+	RenewBefore time.Duration
+	// HTTPClient talks to the ACME server, e.g. trusting the root of a test CA.
+	HTTPClient *http.Client
+}
+
+// Manager obtains a certificate for the domains over ACME and renews it
+// before it expires. The certificate is cached on disk and swapped in the
+// listener once renewed.
+type Manager struct {
+	cfg    Config
+	client *acme.Client
+	cert   atomic.Pointer[tls.Certificate]
+
+	registered bool
+	mu         sync.Mutex
+	// tokens and alpnCerts answer the pending HTTP-01 and TLS-ALPN-01 challenges
+	tokens    map[string]string
+	alpnCerts map[string]*tls.Certificate
+}
+
+// NewManager loads the account key and the cached certificate, creating the
+// cache directory and the key when missing. Nothing is requested from the CA
+// until Run.
+func NewManager(cfg Config) (*Manager, error) {
+	if len(cfg.Domains) == 0 {
+		return nil, errors.New("certs: no domains")
+	}
+
+	switch cfg.Challenge {
+	case ChallengeHTTP01, ChallengeTLSALPN01:
+		for _, domain := range cfg.Domains {
+			if strings.HasPrefix(domain, "*.") {
+				return nil, fmt.Errorf("certs: wildcard domain %s requires the %s challenge", domain, ChallengeDNS01)
+			}
+		}
+	case ChallengeDNS01:
+		if cfg.DNSProvider == nil {
+			return nil, errors.New("certs: the dns-01 challenge requires a DNS provider")
+		}
+	default:
+		return nil, fmt.Errorf("certs: unknown challenge %q", cfg.Challenge)
+	}
+
+	if cfg.RenewBefore <= 0 {
+		cfg.RenewBefore = defaultRenewBefore
+	}
+
+	if err := os.MkdirAll(cfg.CacheDir, 0o700); err != nil {
+		return nil, err
+	}
+
+	key, err := loadOrCreateAccountKey(filepath.Join(cfg.CacheDir, accountKeyFile))
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manager{
+		cfg: cfg,
+		client: &acme.Client{
+			Key:          key,
+			DirectoryURL: cfg.DirectoryURL,
+			HTTPClient:   cfg.HTTPClient,
+			UserAgent:    "StreamX",
+		},
+		tokens:    map[string]string{},
+		alpnCerts: map[string]*tls.Certificate{},
+	}
+
+	cert, err := tls.LoadX509KeyPair(m.cachePath(".crt"), m.cachePath(".key"))
+	if err == nil {
+		if cert.Leaf == nil {
+			cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0])
+		}
+		if err == nil {
+			m.cert.Store(&cert)
+		}
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Warn("Ignored the cached certificate", "dir", cfg.CacheDir, "error", err)
+	}
+
+	return m, nil
+}
+
+// Run obtains the certificate when missing and renews it until ctx is done.
+func (m *Manager) Run(ctx context.Context) {
+	for {
+		wait := checkInterval
+		if m.needsRenewal(time.Now()) {
+			if err := m.obtain(ctx); err != nil {
+				slog.Error("Couldn't obtain the certificate", "domains", m.cfg.Domains, "retry_in", retryInterval, "error", err)
+				wait = retryInterval
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (m *Manager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if len(hello.SupportedProtos) == 1 && hello.SupportedProtos[0] == acme.ALPNProto {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		cert, ok := m.alpnCerts[hello.ServerName]
+		if !ok {
+			return nil, fmt.Errorf("certs: no pending tls-alpn-01 challenge for %s", hello.ServerName)
+		}
+
+		return cert, nil
+	}
+
+	cert := m.cert.Load()
+	if cert == nil {
+		return nil, errNoCertificate
+	}
+
+	return cert, nil
+}
+
+// HandleHTTPChallenge answers the HTTP-01 challenges on
+// /.well-known/acme-challenge/:token, which has to be reachable on port 80.
+func (m *Manager) HandleHTTPChallenge(c *fiber.Ctx) error {
+	m.mu.Lock()
+	response, ok := m.tokens[c.Params("token")]
+	m.mu.Unlock()
+
+	if !ok {
+		return c.SendStatus(fiber.StatusNotFound)
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMETextPlain)
+	return c.SendString(response)
+}
+
+// needsRenewal tells whether there is no certificate, it doesn't cover all
+// the domains or it expires within RenewBefore.
+func (m *Manager) needsRenewal(now time.Time) bool {
+	cert := m.cert.Load()
+	if cert == nil {
+		return true
+	}
+
+	for _, domain := range m.cfg.Domains {
+		if !slices.Contains(cert.Leaf.DNSNames, domain) {
+			return true
+		}
+	}
+
+	return now.Add(m.cfg.RenewBefore).After(cert.Leaf.NotAfter)
+}
+
+func (m *Manager) obtain(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, obtainTimeout)
+	defer cancel()
+
+	if !m.registered {
+		account := &acme.Account{}
+		if m.cfg.Email != "" {
+			account.Contact = []string{"mailto:" + m.cfg.Email}
+		}
+
+		_, err := m.client.Register(ctx, account, acme.AcceptTOS)
+		if err != nil && !errors.Is(err, acme.ErrAccountAlreadyExists) {
+			return fmt.Errorf("couldn't register the account: %w", err)
+		}
+		m.registered = true
+	}
+
+	order, err := m.client.AuthorizeOrder(ctx, acme.DomainIDs(m.cfg.Domains...))
+	if err != nil {
+		return fmt.Errorf("couldn't create the order: %w", err)
+	}
+
+	for _, authzURL := range order.AuthzURLs {
+		if err := m.authorize(ctx, authzURL); err != nil {
+			return err
+		}
+	}
+
+	order, err = m.client.WaitOrder(ctx, order.URI)
+	if err != nil {
+		return fmt.Errorf("order not ready: %w", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{DNSNames: m.cfg.Domains}, key)
+	if err != nil {
+		return err
+	}
+
+	chain, _, err := m.client.CreateOrderCert(ctx, order.FinalizeURL, csr, true)
+	if err != nil {
+		return fmt.Errorf("couldn't finalize the order: %w", err)
+	}
+
+	leaf, err := x509.ParseCertificate(chain[0])
+	if err != nil {
+		return err
+	}
+
+	if err := m.save(chain, key); err != nil {
+		// the certificate is still served until the next restart
+		slog.Warn("Couldn't cache the certificate", "dir", m.cfg.CacheDir, "error", err)
+	}
+
+	m.cert.Store(&tls.Certificate{Certificate: chain, PrivateKey: key, Leaf: leaf})
+	slog.Info("Obtained the certificate", "domains", m.cfg.Domains, "expires", leaf.NotAfter)
+	return nil
+}
+
+// authorize solves the configured challenge of the authorization, unless the
+// CA still holds a valid one for the domain.
+func (m *Manager) authorize(ctx context.Context, authzURL string) error {
+	authz, err := m.client.GetAuthorization(ctx, authzURL)
+	if err != nil {
+		return err
+	}
+
+	if authz.Status == acme.StatusValid {
+		return nil
+	}
+
+	domain := authz.Identifier.Value
+	var challenge *acme.Challenge
+	for _, c := range authz.Challenges {
+		if c.Type == m.cfg.Challenge {
+			challenge = c
+			break
+		}
+	}
+	if challenge == nil {
+		return fmt.Errorf("the CA offers no %s challenge for %s", m.cfg.Challenge, domain)
+	}
+
+	cleanUp, err := m.prepare(ctx, domain, challenge.Token)
+	if err != nil {
+		return fmt.Errorf("couldn't prepare the %s challenge of %s: %w", m.cfg.Challenge, domain, err)
+	}
+	defer cleanUp()
+
+	if _, err := m.client.Accept(ctx, challenge); err != nil {
+		return fmt.Errorf("couldn't accept the challenge of %s: %w", domain, err)
+	}
+
+	if _, err := m.client.WaitAuthorization(ctx, authz.URI); err != nil {
+		return fmt.Errorf("couldn't authorize %s: %w", domain, err)
+	}
+
+	return nil
+}
+
+// prepare sets up the response to the challenge and returns the function
+// removing it.
+func (m *Manager) prepare(ctx context.Context, domain, token string) (func(), error) {
+	switch m.cfg.Challenge {
+	case ChallengeHTTP01:
+		response, err := m.client.HTTP01ChallengeResponse(token)
+		if err != nil {
+			return nil, err
+		}
+
+		m.mu.Lock()
+		m.tokens[token] = response
+		m.mu.Unlock()
+		return func() {
+			m.mu.Lock()
+			delete(m.tokens, token)
+			m.mu.Unlock()
+		}, nil
+	case ChallengeTLSALPN01:
+		cert, err := m.client.TLSALPN01ChallengeCert(token, domain)
+		if err != nil {
+			return nil, err
+		}
+
+		m.mu.Lock()
+		m.alpnCerts[domain] = &cert
+		m.mu.Unlock()
+		return func() {
+			m.mu.Lock()
+			delete(m.alpnCerts, domain)
+			m.mu.Unlock()
+		}, nil
+	default:
+		value, err := m.client.DNS01ChallengeRecord(token)
+		if err != nil {
+			return nil, err
+		}
+
+		fqdn := "_acme-challenge." + domain
+		if err := m.cfg.DNSProvider.Present(ctx, fqdn, value); err != nil {
+			return nil, err
+		}
+
+		waitForTXT(ctx, fqdn, value, m.cfg.DNSPropagationTimeout)
+		return func() {
+			// the order context may be done already
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+
+			if err := m.cfg.DNSProvider.CleanUp(ctx, fqdn, value); err != nil {
+				slog.Warn("Couldn't remove the challenge record", "record", fqdn, "error", err)
+			}
+		}, nil
+	}
+}
+
+// cachePath returns the path of a file of the certificate, named after its
+// first domain.
+func (m *Manager) cachePath(extension string) string {
+	name := strings.ReplaceAll(m.cfg.Domains[0], "*", "_wildcard")
+	return filepath.Join(m.cfg.CacheDir, name+extension)
+}
+
+func (m *Manager) save(chain [][]byte, key *ecdsa.PrivateKey) error {
+	certPEM := []byte{}
+	for _, der := range chain {
+		certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	}
+
+	keyPEM, err := encodeKey(key)
+	if err != nil {
+		return err
+	}
+
+	// the key goes first, a certificate without its key is never loaded
+	if err := writeFile(m.cachePath(".key"), keyPEM); err != nil {
+		return err
+	}
+
+	return writeFile(m.cachePath(".crt"), certPEM)
+}
+
+func loadOrCreateAccountKey(path string) (crypto.Signer, error) {
+	raw, err := os.ReadFile(path)
+	if err == nil {
+		block, _ := pem.Decode(raw)
+		if block == nil {
+			return nil, fmt.Errorf("certs: invalid account key %s", path)
+		}
+
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPEM, err := encodeKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return key, writeFile(path, keyPEM)
+}
+
+func encodeKey(key *ecdsa.PrivateKey) ([]byte, error) {
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), nil
+}
+
+// writeFile replaces the file at once, readers never see it half written.
+func writeFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,118 @@
+// Package certs provides the certificates of the HTTPS listener, either read
+// from files written by another tool or obtained and renewed over ACME. Both
+// are picked up by the listener without restarting it.
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme"
+)
+
+// reloadInterval is how often the files are checked for a new certificate.
+const reloadInterval = time.Minute
+
+// Source returns the certificate of a TLS handshake.
+type Source interface {
+	GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error)
+}
+
+// TLSConfig returns the configuration of a listener serving the certificates
+// of source. It accepts the ACME TLS-ALPN-01 protocol, which only a Manager
+// answers.
+func TLSConfig(source Source) *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: source.GetCertificate,
+		NextProtos:     []string{"http/1.1", acme.ALPNProto},
+	}
+}
+
+// FileSource serves a certificate and key pair from PEM files and reloads
+// them once they change, e.g. after a renewal script replaced them.
+type FileSource struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+	checked time.Time
+}
+
+// LoadFiles reads the certificate and key, they have to exist already.
+func LoadFiles(certFile, keyFile string) (*FileSource, error) {
+	s := &FileSource{certFile: certFile, keyFile: keyFile}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *FileSource) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if time.Since(s.checked) >= reloadInterval {
+		s.checked = time.Now()
+		if modTime, err := s.lastModified(); err == nil && !modTime.Equal(s.modTime) {
+			if err := s.load(); err != nil {
+				// keep serving the previous one, the files may be half written
+				slog.Warn("Couldn't reload the certificate", "cert", s.certFile, "error", err)
+			} else {
+				slog.Info("Reloaded the certificate", "cert", s.certFile, "expires", s.cert.Leaf.NotAfter)
+			}
+		}
+	}
+
+	return s.cert, nil
+}
+
+func (s *FileSource) load() error {
+	modTime, err := s.lastModified()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(s.certFile, s.keyFile)
+	if err != nil {
+		return fmt.Errorf("certs: couldn't load %s: %w", s.certFile, err)
+	}
+
+	if cert.Leaf == nil {
+		if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return err
+		}
+	}
+
+	s.cert = &cert
+	s.modTime = modTime
+	s.checked = time.Now()
+	return nil
+}
+
+// lastModified returns the latest modification time of both files.
+func (s *FileSource) lastModified() (time.Time, error) {
+	certInfo, err := os.Stat(s.certFile)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	keyInfo, err := os.Stat(s.keyFile)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if keyInfo.ModTime().After(certInfo.ModTime()) {
+		return keyInfo.ModTime(), nil
+	}
+
+	return certInfo.ModTime(), nil
+}
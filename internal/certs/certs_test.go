@@ -0,0 +1,219 @@
+package certs
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/acme"
+)
+
+func TestFileSourceReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "server.pem"), filepath.Join(dir, "server.key")
+	writePair(t, certFile, keyFile, "old.example.com", time.Now().Add(time.Hour))
+
+	source, err := LoadFiles(certFile, keyFile)
+	require.NoError(t, err)
+	cert, err := source.GetCertificate(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"old.example.com"}, cert.Leaf.DNSNames)
+
+	writePair(t, certFile, keyFile, "new.example.com", time.Now().Add(time.Hour))
+	future := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(certFile, future, future))
+
+	// files are only checked once a minute
+	cert, err = source.GetCertificate(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"old.example.com"}, cert.Leaf.DNSNames)
+
+	source.checked = time.Time{}
+	cert, err = source.GetCertificate(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"new.example.com"}, cert.Leaf.DNSNames)
+}
+
+func TestManagerCachedCertificate(t *testing.T) {
+	dir := t.TempDir()
+	notAfter := time.Now().Add(60 * 24 * time.Hour)
+	writePair(t, filepath.Join(dir, "example.com.crt"), filepath.Join(dir, "example.com.key"), "example.com", notAfter)
+
+	m, err := NewManager(Config{Domains: []string{"example.com"}, CacheDir: dir, Challenge: ChallengeHTTP01})
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(dir, accountKeyFile))
+
+	cert, err := m.GetCertificate(&tls.ClientHelloInfo{ServerName: "example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"example.com"}, cert.Leaf.DNSNames)
+
+	assert.False(t, m.needsRenewal(time.Now()))
+	assert.True(t, m.needsRenewal(notAfter.Add(-29*24*time.Hour)))
+
+	m.cfg.Domains = []string{"example.com", "www.example.com"}
+	assert.True(t, m.needsRenewal(time.Now()))
+}
+
+func TestManagerWithoutCertificate(t *testing.T) {
+	m, err := NewManager(Config{Domains: []string{"example.com"}, CacheDir: t.TempDir(), Challenge: ChallengeTLSALPN01})
+	require.NoError(t, err)
+
+	_, err = m.GetCertificate(&tls.ClientHelloInfo{ServerName: "example.com"})
+	assert.ErrorIs(t, err, errNoCertificate)
+	assert.True(t, m.needsRenewal(time.Now()))
+
+	challenge := &tls.Certificate{}
+	m.alpnCerts["example.com"] = challenge
+	cert, err := m.GetCertificate(&tls.ClientHelloInfo{ServerName: "example.com", SupportedProtos: []string{acme.ALPNProto}})
+	require.NoError(t, err)
+	assert.Same(t, challenge, cert)
+}
+
+func TestNewManagerValidation(t *testing.T) {
+	_, err := NewManager(Config{Domains: []string{"*.example.com"}, CacheDir: t.TempDir(), Challenge: ChallengeHTTP01})
+	assert.ErrorContains(t, err, "requires the dns-01 challenge")
+
+	_, err = NewManager(Config{Domains: []string{"example.com"}, CacheDir: t.TempDir(), Challenge: ChallengeDNS01})
+	assert.ErrorContains(t, err, "requires a DNS provider")
+}
+
+func TestCloudflare(t *testing.T) {
+	records := map[string]cloudflareRecord{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /zones", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer TOKEN", r.Header.Get("Authorization"))
+		if r.URL.Query().Get("name") == "example.com" {
+			_, _ = w.Write([]byte(`{"success":true,"result":[{"id":"ZONE"}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"success":true,"result":[]}`))
+	})
+	mux.HandleFunc("POST /zones/ZONE/dns_records", func(w http.ResponseWriter, r *http.Request) {
+		record := cloudflareRecord{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&record))
+		record.ID = "RECORD"
+		records[record.ID] = record
+		_, _ = w.Write([]byte(`{"success":true,"result":{}}`))
+	})
+	mux.HandleFunc("GET /zones/ZONE/dns_records", func(w http.ResponseWriter, r *http.Request) {
+		result := []cloudflareRecord{}
+		for _, record := range records {
+			if record.Name == r.URL.Query().Get("name") {
+				result = append(result, record)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(cloudflareResponse[[]cloudflareRecord]{Success: true, Result: result})
+	})
+	mux.HandleFunc("DELETE /zones/ZONE/dns_records/{id}", func(w http.ResponseWriter, r *http.Request) {
+		delete(records, r.PathValue("id"))
+		_, _ = w.Write([]byte(`{"success":true,"result":{}}`))
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mux.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	cf := NewCloudflareWithBaseURL(server.URL, "TOKEN")
+	require.NoError(t, cf.Present(context.Background(), "_acme-challenge.www.example.com", "VALUE"))
+	assert.Equal(t, cloudflareRecord{ID: "RECORD", Type: "TXT", Name: "_acme-challenge.www.example.com", Content: "VALUE", TTL: challengeTTL}, records["RECORD"])
+
+	require.NoError(t, cf.CleanUp(context.Background(), "_acme-challenge.www.example.com", "VALUE"))
+	assert.Empty(t, records)
+
+	err := cf.Present(context.Background(), "_acme-challenge.example.org", "VALUE")
+	assert.ErrorContains(t, err, "no cloudflare zone")
+}
+
+// TestManagerPebble obtains a certificate from a Pebble test CA over HTTP-01:
+//
+//	pebble -config test/config/pebble-config.json
+//	PEBBLE_DIRECTORY_URL=https://localhost:14000/dir PEBBLE_DOMAIN=localhost go test ./internal/certs
+//
+// Pebble validates HTTP-01 on port 5002 with its default configuration.
+func TestManagerPebble(t *testing.T) {
+	directoryURL := os.Getenv("PEBBLE_DIRECTORY_URL")
+	if directoryURL == "" {
+		t.Skip("PEBBLE_DIRECTORY_URL not set")
+	}
+
+	domain := os.Getenv("PEBBLE_DOMAIN")
+	if domain == "" {
+		domain = "localhost"
+	}
+
+	m, err := NewManager(Config{
+		Domains:      []string{domain},
+		DirectoryURL: directoryURL,
+		CacheDir:     t.TempDir(),
+		Challenge:    ChallengeHTTP01,
+		// Pebble serves its API with a certificate of its own test root
+		HTTPClient: &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}},
+	})
+	require.NoError(t, err)
+
+	ln, err := net.Listen("tcp", ":5002")
+	require.NoError(t, err)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.mu.Lock()
+		response, ok := m.tokens[strings.TrimPrefix(r.URL.Path, "/.well-known/acme-challenge/")]
+		m.mu.Unlock()
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(response))
+	})}
+	go func() { _ = server.Serve(ln) }()
+	defer server.Close()
+
+	require.NoError(t, m.obtain(context.Background()))
+	cert, err := m.GetCertificate(&tls.ClientHelloInfo{ServerName: domain})
+	require.NoError(t, err)
+	assert.Contains(t, cert.Leaf.DNSNames, domain)
+	assert.False(t, m.needsRenewal(time.Now()))
+
+	// a restart serves the cached certificate
+	restarted, err := NewManager(m.cfg)
+	require.NoError(t, err)
+	cached, err := restarted.GetCertificate(&tls.ClientHelloInfo{ServerName: domain})
+	require.NoError(t, err)
+	assert.Equal(t, cert.Certificate, cached.Certificate)
+}
+
+func writePair(t *testing.T, certFile, keyFile, domain string, notAfter time.Time) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		DNSNames:     []string{domain},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyPEM, err := encodeKey(key)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, keyPEM, 0o600))
+}
@@ -0,0 +1,200 @@
+package certs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+
+	"github.com/dbytex91/streamx/internal/logging"
+)
+
+const (
+	cloudflareBaseURL = "https://api.cloudflare.com/client/v4"
+	challengeTTL      = 120
+	propagationPoll   = 5 * time.Second
+)
+
+// DNSProvider creates and removes the TXT records of DNS-01 challenges. fqdn
+// is the record name, e.g. "_acme-challenge.example.com", without trailing dot.
+type DNSProvider interface {
+	Present(ctx context.Context, fqdn, value string) error
+	CleanUp(ctx context.Context, fqdn, value string) error
+}
+
+// Cloudflare manages the records through the Cloudflare API with a token
+// allowed to edit the DNS of the zone.
+type Cloudflare struct {
+	client *resty.Client
+}
+
+type cloudflareResponse[T any] struct {
+	Success bool                `json:"success"`
+	Errors  []cloudflareMessage `json:"errors"`
+	Result  T                   `json:"result"`
+}
+
+type cloudflareMessage struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type cloudflareRecord struct {
+	ID      string `json:"id,omitempty"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	TTL     int    `json:"ttl"`
+}
+
+func NewCloudflare(apiToken string) *Cloudflare {
+	return NewCloudflareWithBaseURL(cloudflareBaseURL, apiToken)
+}
+
+func NewCloudflareWithBaseURL(baseURL, apiToken string) *Cloudflare {
+	return &Cloudflare{
+		client: resty.New().
+			SetBaseURL(baseURL).
+			SetAuthToken(apiToken).
+			SetLogger(logging.RestyLogger{}),
+	}
+}
+
+// NewDNSProvider returns the provider of the name, only "cloudflare" for now.
+func NewDNSProvider(name, apiToken string) (DNSProvider, error) {
+	switch name {
+	case "cloudflare":
+		if apiToken == "" {
+			return nil, errors.New("certs: the cloudflare DNS provider requires an API token")
+		}
+		return NewCloudflare(apiToken), nil
+	default:
+		return nil, fmt.Errorf("certs: unknown DNS provider %q", name)
+	}
+}
+
+func (cf *Cloudflare) Present(ctx context.Context, fqdn, value string) error {
+	zoneID, err := cf.zoneID(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+
+	result := cloudflareResponse[cloudflareRecord]{}
+	resp, err := cf.client.R().
+		SetContext(ctx).
+		SetBody(cloudflareRecord{Type: "TXT", Name: fqdn, Content: value, TTL: challengeTTL}).
+		SetResult(&result).
+		SetError(&result).
+		Post("/zones/" + zoneID + "/dns_records")
+
+	return cloudflareError(resp, err, result.Success, result.Errors)
+}
+
+func (cf *Cloudflare) CleanUp(ctx context.Context, fqdn, value string) error {
+	zoneID, err := cf.zoneID(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+
+	result := cloudflareResponse[[]cloudflareRecord]{}
+	resp, err := cf.client.R().
+		SetContext(ctx).
+		SetQueryParams(map[string]string{"type": "TXT", "name": fqdn}).
+		SetResult(&result).
+		SetError(&result).
+		Get("/zones/" + zoneID + "/dns_records")
+	if err := cloudflareError(resp, err, result.Success, result.Errors); err != nil {
+		return err
+	}
+
+	for _, record := range result.Result {
+		if record.Content != value && record.Content != `"`+value+`"` {
+			continue
+		}
+
+		deleted := cloudflareResponse[struct{}]{}
+		resp, err := cf.client.R().
+			SetContext(ctx).
+			SetResult(&deleted).
+			SetError(&deleted).
+			Delete("/zones/" + zoneID + "/dns_records/" + record.ID)
+		if err := cloudflareError(resp, err, deleted.Success, deleted.Errors); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// zoneID finds the zone of the record, trying its parent domains from the
+// closest one.
+func (cf *Cloudflare) zoneID(ctx context.Context, fqdn string) (string, error) {
+	labels := strings.Split(fqdn, ".")
+	for i := 1; i < len(labels)-1; i++ {
+		result := cloudflareResponse[[]struct {
+			ID string `json:"id"`
+		}]{}
+		resp, err := cf.client.R().
+			SetContext(ctx).
+			SetQueryParam("name", strings.Join(labels[i:], ".")).
+			SetResult(&result).
+			SetError(&result).
+			Get("/zones")
+		if err := cloudflareError(resp, err, result.Success, result.Errors); err != nil {
+			return "", err
+		}
+
+		if len(result.Result) > 0 {
+			return result.Result[0].ID, nil
+		}
+	}
+
+	return "", fmt.Errorf("certs: no cloudflare zone for %s", fqdn)
+}
+
+func cloudflareError(resp *resty.Response, err error, success bool, errs []cloudflareMessage) error {
+	if err != nil {
+		return err
+	}
+
+	if resp.IsError() || !success {
+		messages := make([]string, 0, len(errs))
+		for _, e := range errs {
+			messages = append(messages, fmt.Sprintf("%d %s", e.Code, e.Message))
+		}
+		return fmt.Errorf("certs: cloudflare answered %d: %s", resp.StatusCode(), strings.Join(messages, ", "))
+	}
+
+	return nil
+}
+
+// waitForTXT polls the resolver until the record shows up or timeout is over.
+// Resolvers may differ from the CA's, so the CA is asked to validate either way.
+func waitForTXT(ctx context.Context, fqdn, value string, timeout time.Duration) {
+	if timeout <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		records, _ := net.DefaultResolver.LookupTXT(ctx, fqdn)
+		if slices.Contains(records, value) {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			slog.Warn("Challenge record not visible yet, validating anyway", "record", fqdn, "timeout", timeout)
+			return
+		case <-time.After(propagationPoll):
+		}
+	}
+}
@@ -1,13 +1,23 @@
 package cinemeta
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
 	"strconv"
 	"strings"
 
+	"github.com/dbytex91/streamx/internal/metadata"
 	"github.com/dbytex91/streamx/internal/model"
+	"github.com/dbytex91/streamx/internal/tracing"
 	"github.com/go-resty/resty/v2"
 )
 
+const defaultBaseURL = "https://v3-cinemeta.strem.io"
+
+var runtimePattern = regexp.MustCompile(`^\s*(?:(\d+)\s*h)?\s*(?:(\d+)\s*min)?`)
+
 type CineMeta struct {
 	client *resty.Client
 }
@@ -17,43 +27,56 @@ type MovieInfoResponse struct {
 }
 
 type MetaInfo struct {
-	Name   string `json:"name"`
-	Year   string `json:"year"`
-	IMDBID string `json:"imdb_id"`
+	Name    string  `json:"name"`
+	Year    string  `json:"year"`
+	IMDBID  string  `json:"imdb_id"`
+	Runtime string  `json:"runtime"`
+	Videos  []Video `json:"videos"`
+}
+
+type Video struct {
+	Name    string `json:"name"`
+	Title   string `json:"title"`
+	Season  int    `json:"season"`
+	Episode int    `json:"episode"`
+	Number  int    `json:"number"`
 }
 
 func New() *CineMeta {
+	return NewWithBaseURL(defaultBaseURL)
+}
+
+func NewWithBaseURL(baseURL string) *CineMeta {
 	return &CineMeta{
-		client: resty.New().SetBaseURL("https://v3-cinemeta.strem.io"),
+		client: tracing.InstrumentResty(resty.New().SetBaseURL(baseURL), "cinemeta"),
 	}
 }
 
-func (c *CineMeta) GetMovieById(id string) (*model.MetaInfo, error) {
-	resp, err := c.client.R().SetResult(&MovieInfoResponse{}).Get("/meta/movie/" + id + ".json")
+func (c *CineMeta) GetMovieById(ctx context.Context, id string) (*model.MetaInfo, error) {
+	meta, err := c.getMeta(ctx, "movie", id)
 	if err != nil {
 		return nil, err
 	}
 
-	result := resp.Result().(*MovieInfoResponse)
-	year, _ := strconv.Atoi(result.Meta.Year)
-	imdbID, err := strconv.Atoi(strings.TrimPrefix(result.Meta.IMDBID, "tt"))
+	year, _ := strconv.Atoi(meta.Year)
+	imdbID, _ := strconv.Atoi(strings.TrimPrefix(meta.IMDBID, "tt"))
 
 	return &model.MetaInfo{
-		Name:     result.Meta.Name,
+		Name:     meta.Name,
 		IMDBID:   uint(imdbID),
 		FromYear: year,
 		ToYear:   year,
+		Runtime:  parseRuntime(meta.Runtime),
 	}, nil
 }
 
-func (c CineMeta) GetSeriesById(id string) (*model.MetaInfo, error) {
-	resp, err := c.client.R().SetResult(&MovieInfoResponse{}).Get("/meta/series/" + id + ".json")
+func (c *CineMeta) GetSeriesById(ctx context.Context, id string) (*model.MetaInfo, error) {
+	meta, err := c.getMeta(ctx, "series", id)
 	if err != nil {
 		return nil, err
 	}
 
-	result := resp.Result().(*MovieInfoResponse)
-	tokens := strings.Split(result.Meta.Year, "–")
+	tokens := strings.Split(meta.Year, "–")
 	fromYear := 0
 	toYear := 0
 	if len(tokens) > 1 {
@@ -63,12 +86,84 @@ func (c CineMeta) GetSeriesById(id string) (*model.MetaInfo, error) {
 		fromYear, _ = strconv.Atoi(tokens[0])
 		toYear = fromYear
 	}
-	imdbID, err := strconv.Atoi(strings.TrimPrefix(result.Meta.IMDBID, "tt"))
+	imdbID, _ := strconv.Atoi(strings.TrimPrefix(meta.IMDBID, "tt"))
+	runtime := parseRuntime(meta.Runtime)
+
+	episodes := make([]model.Episode, 0, len(meta.Videos))
+	for _, video := range meta.Videos {
+		episode := video.Episode
+		if episode == 0 {
+			episode = video.Number
+		}
+
+		title := video.Name
+		if title == "" {
+			title = video.Title
+		}
+
+		episodes = append(episodes, model.Episode{
+			Season:  video.Season,
+			Episode: episode,
+			Title:   title,
+			Runtime: runtime,
+		})
+	}
 
 	return &model.MetaInfo{
-		Name:     result.Meta.Name,
+		Name:     meta.Name,
 		IMDBID:   uint(imdbID),
 		FromYear: fromYear,
 		ToYear:   toYear,
+		Runtime:  runtime,
+		Episodes: episodes,
 	}, nil
 }
+
+// Ping checks that Cinemeta answers by loading its manifest.
+func (c *CineMeta) Ping(ctx context.Context) error {
+	resp, err := c.client.R().SetContext(ctx).Get("/manifest.json")
+	if err != nil {
+		return err
+	}
+
+	if resp.IsError() {
+		return fmt.Errorf("error response from cinemeta: %s", resp.Status())
+	}
+
+	return nil
+}
+
+func (c *CineMeta) getMeta(ctx context.Context, contentType string, id string) (*MetaInfo, error) {
+	resp, err := c.client.R().SetContext(ctx).SetResult(&MovieInfoResponse{}).Get("/meta/" + contentType + "/" + id + ".json")
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode() == http.StatusNotFound {
+		return nil, metadata.ErrNotFound
+	}
+
+	if resp.IsError() {
+		return nil, fmt.Errorf("error response from cinemeta: %s", resp.Status())
+	}
+
+	result := resp.Result().(*MovieInfoResponse)
+	if result.Meta.Name == "" {
+		// cinemeta answers unknown ids with 200 and an empty meta
+		return nil, metadata.ErrNotFound
+	}
+
+	return &result.Meta, nil
+}
+
+// parseRuntime converts "136 min" or "2h 16min" to minutes.
+func parseRuntime(runtime string) int {
+	match := runtimePattern.FindStringSubmatch(runtime)
+	if match == nil {
+		return 0
+	}
+
+	hours, _ := strconv.Atoi(match[1])
+	minutes, _ := strconv.Atoi(match[2])
+	return hours*60 + minutes
+}
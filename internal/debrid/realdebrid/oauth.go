@@ -0,0 +1,181 @@
+package realdebrid
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+
+	"github.com/dbytex91/streamx/internal/logging"
+)
+
+const (
+	// OpenSourceClientID is the client id Real-Debrid provides to open source
+	// apps, the device flow issues a client secret of its own for each user.
+	OpenSourceClientID = "X345QLLY6Y2GS"
+	OAuthURL           = "https://api.real-debrid.com/oauth/v2"
+
+	deviceGrantType = "http://oauth.net/grant_type/device/1.0"
+)
+
+var (
+	// ErrAuthorizationPending is returned while the user hasn't entered the code yet.
+	ErrAuthorizationPending = errors.New("realdebrid: authorization pending")
+	// ErrDeviceCodeExpired is returned once the code can't be entered anymore,
+	// the flow has to start over with a new one.
+	ErrDeviceCodeExpired = errors.New("realdebrid: device code expired")
+)
+
+// OAuth implements the device-code flow, which links an account without the
+// user copying their private API token.
+type OAuth struct {
+	client *resty.Client
+
+	// expiries of the device codes handed out, Real-Debrid keeps answering
+	// expired codes as pending
+	mu       sync.Mutex
+	expiries map[string]time.Time
+}
+
+type DeviceCode struct {
+	DeviceCode            string `json:"device_code"`
+	UserCode              string `json:"user_code"`
+	Interval              int    `json:"interval"`
+	ExpiresIn             int    `json:"expires_in"`
+	VerificationURL       string `json:"verification_url"`
+	DirectVerificationURL string `json:"direct_verification_url"`
+}
+
+type Credentials struct {
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+}
+
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	ExpiresIn    int    `json:"expires_in"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+func NewOAuth(baseURL string) *OAuth {
+	return &OAuth{
+		client: resty.New().
+			SetBaseURL(baseURL).
+			SetHeader("Accept", "application/json").
+			SetError(ErrorResponse{}).
+			SetLogger(logging.RestyLogger{}),
+		expiries: map[string]time.Time{},
+	}
+}
+
+// DeviceCode starts the flow, the user enters UserCode at VerificationURL.
+func (o *OAuth) DeviceCode() (*DeviceCode, error) {
+	result := &DeviceCode{}
+	resp, err := o.client.R().
+		SetQueryParams(map[string]string{
+			"client_id":       OpenSourceClientID,
+			"new_credentials": "yes",
+		}).
+		SetResult(result).
+		Get("/device/code")
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.IsError() {
+		return nil, resp.Error().(error)
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	now := time.Now()
+	for code, expiry := range o.expiries {
+		if now.After(expiry) {
+			delete(o.expiries, code)
+		}
+	}
+	o.expiries[result.DeviceCode] = now.Add(time.Duration(result.ExpiresIn) * time.Second)
+
+	return result, nil
+}
+
+// Credentials returns the client id and secret issued to the user once they
+// entered the code, ErrAuthorizationPending until then and
+// ErrDeviceCodeExpired once the code expired.
+func (o *OAuth) Credentials(deviceCode string) (*Credentials, error) {
+	if o.expired(deviceCode) {
+		return nil, ErrDeviceCodeExpired
+	}
+
+	result := &Credentials{}
+	resp, err := o.client.R().
+		SetQueryParams(map[string]string{
+			"client_id": OpenSourceClientID,
+			"code":      deviceCode,
+		}).
+		SetResult(result).
+		Get("/device/credentials")
+	if err != nil {
+		return nil, err
+	}
+
+	// Real-Debrid answers with a client error until the code is entered
+	if resp.StatusCode() >= 500 {
+		return nil, resp.Error().(error)
+	}
+
+	if resp.IsError() || result.ClientSecret == "" {
+		return nil, ErrAuthorizationPending
+	}
+
+	o.mu.Lock()
+	delete(o.expiries, deviceCode)
+	o.mu.Unlock()
+
+	return result, nil
+}
+
+// expired tells whether a device code handed out by DeviceCode expired, codes
+// of an earlier run are left to Real-Debrid.
+func (o *OAuth) expired(deviceCode string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	expiry, ok := o.expiries[deviceCode]
+	return ok && !time.Now().Before(expiry)
+}
+
+// Token exchanges the device code for the first access token.
+func (o *OAuth) Token(creds *Credentials, deviceCode string) (*Token, error) {
+	return o.token(creds, deviceCode)
+}
+
+// Refresh returns a new access token. The refresh token is rotated, only the
+// one of the returned token stays valid.
+func (o *OAuth) Refresh(creds *Credentials, refreshToken string) (*Token, error) {
+	return o.token(creds, refreshToken)
+}
+
+func (o *OAuth) token(creds *Credentials, code string) (*Token, error) {
+	result := &Token{}
+	resp, err := o.client.R().
+		SetFormData(map[string]string{
+			"client_id":     creds.ClientID,
+			"client_secret": creds.ClientSecret,
+			"code":          code,
+			"grant_type":    deviceGrantType,
+		}).
+		SetResult(result).
+		Post("/token")
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.IsError() {
+		return nil, resp.Error().(error)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,102 @@
+package realdebrid_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dbytex91/streamx/internal/debrid/realdebrid"
+)
+
+func TestDeviceFlow(t *testing.T) {
+	authorized := false
+	// refresh tokens are rotated, only the last one issued is valid
+	refreshToken := "REFRESH1"
+	mux := http.NewServeMux()
+	mux.HandleFunc("/device/code", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, realdebrid.OpenSourceClientID, r.URL.Query().Get("client_id"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"device_code":"DEVICE","user_code":"ABCD","interval":5,"expires_in":600,"verification_url":"https://real-debrid.com/device"}`))
+	})
+	mux.HandleFunc("/device/credentials", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "DEVICE", r.URL.Query().Get("code"))
+		w.Header().Set("Content-Type", "application/json")
+		if !authorized {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"error":"authorization_pending","error_code":8}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"client_id":"USERCLIENT","client_secret":"SECRET"}`))
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "USERCLIENT", r.PostForm.Get("client_id"))
+		assert.Equal(t, "SECRET", r.PostForm.Get("client_secret"))
+		assert.Equal(t, "http://oauth.net/grant_type/device/1.0", r.PostForm.Get("grant_type"))
+		w.Header().Set("Content-Type", "application/json")
+		switch r.PostForm.Get("code") {
+		case "DEVICE":
+			_, _ = w.Write([]byte(`{"access_token":"ACCESS1","expires_in":3600,"token_type":"Bearer","refresh_token":"REFRESH1"}`))
+		case refreshToken:
+			refreshToken = "REFRESH2"
+			_, _ = w.Write([]byte(`{"access_token":"ACCESS2","expires_in":3600,"token_type":"Bearer","refresh_token":"REFRESH2"}`))
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"bad_token","error_code":9}`))
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	oauth := realdebrid.NewOAuth(server.URL)
+	code, err := oauth.DeviceCode()
+	require.NoError(t, err)
+	assert.Equal(t, "ABCD", code.UserCode)
+
+	_, err = oauth.Credentials(code.DeviceCode)
+	assert.ErrorIs(t, err, realdebrid.ErrAuthorizationPending)
+
+	authorized = true
+	creds, err := oauth.Credentials(code.DeviceCode)
+	require.NoError(t, err)
+
+	token, err := oauth.Token(creds, code.DeviceCode)
+	require.NoError(t, err)
+	assert.Equal(t, "ACCESS1", token.AccessToken)
+
+	token, err = oauth.Refresh(creds, token.RefreshToken)
+	require.NoError(t, err)
+	assert.Equal(t, "REFRESH2", token.RefreshToken)
+
+	_, err = oauth.Refresh(creds, "REFRESH1")
+	assert.Error(t, err)
+}
+
+func TestDeviceCodeExpired(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/device/code", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"device_code":"DEVICE","user_code":"ABCD","interval":5,"expires_in":0,"verification_url":"https://real-debrid.com/device"}`))
+	})
+	mux.HandleFunc("/device/credentials", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"error":"authorization_pending","error_code":8}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	oauth := realdebrid.NewOAuth(server.URL)
+	code, err := oauth.DeviceCode()
+	require.NoError(t, err)
+
+	_, err = oauth.Credentials(code.DeviceCode)
+	assert.ErrorIs(t, err, realdebrid.ErrDeviceCodeExpired)
+
+	// codes handed out before a restart are left to Real-Debrid
+	_, err = oauth.Credentials("OTHER")
+	assert.ErrorIs(t, err, realdebrid.ErrAuthorizationPending)
+}
@@ -1,13 +1,18 @@
 package realdebrid
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"strings"
 
 	"github.com/go-resty/resty/v2"
-	"github.com/gofiber/fiber/v2/log"
+
+	"github.com/dbytex91/streamx/internal/logging"
+	"github.com/dbytex91/streamx/internal/metrics"
+	"github.com/dbytex91/streamx/internal/tracing"
 )
 
 var (
@@ -47,7 +52,22 @@ func New(apiToken string, ipAddress string) *RealDebrid {
 		SetHeader("Accept", "application/json").
 		SetAuthScheme("Bearer").
 		SetError(ErrorResponse{}).
-		SetAuthToken(apiToken)
+		SetLogger(logging.RestyLogger{}).
+		SetAuthToken(apiToken).
+		OnAfterResponse(func(_ *resty.Client, resp *resty.Response) error {
+			metrics.ObserveDebrid(endpoint(resp.Request.URL), resp.StatusCode())
+			return nil
+		}).
+		OnError(func(req *resty.Request, err error) {
+			status := 0
+			if respErr, ok := err.(*resty.ResponseError); ok {
+				// the body couldn't be parsed, later hooks didn't run
+				status = respErr.Response.StatusCode()
+			}
+			metrics.ObserveDebrid(endpoint(req.URL), status)
+		})
+
+	tracing.InstrumentResty(client, "realdebrid")
 
 	if ipAddress != "" {
 		client.SetFormData(map[string]string{
@@ -61,18 +81,31 @@ func New(apiToken string, ipAddress string) *RealDebrid {
 	}
 }
 
-func (rd *RealDebrid) GetFiles(infoHashs []string) (map[string][]*File, error) {
+// endpoint strips ids and info hashes from the path of an API call so it can
+// be used as a metric label.
+func endpoint(rawURL string) string {
+	path, _, _ := strings.Cut(strings.TrimPrefix(rawURL, "https://api.real-debrid.com/rest/1.0"), "?")
+	parts := strings.SplitN(strings.Trim(path, "/"), "/", 3)
+	if len(parts) > 2 {
+		parts = parts[:2]
+	}
+
+	return strings.Join(parts, "/")
+}
+
+func (rd *RealDebrid) GetFiles(ctx context.Context, infoHashs []string) (map[string][]*File, error) {
 	result := map[string]safeCatchedTorrentResponse{}
 	resp, err := rd.client.R().
+		SetContext(ctx).
 		SetResult(&result).
 		Get("/torrents/instantAvailability/" + strings.Join(infoHashs, "/"))
 	if err != nil {
-		log.Errorf("Failed to get result from Debrid, err: %v", err)
+		slog.ErrorContext(ctx, "Failed to get result from Debrid", "error", err)
 		return nil, err
 	}
 
 	if resp.IsError() {
-		log.Errorf("Failed to get result from Debrid, err: %v", resp.Error())
+		slog.ErrorContext(ctx, "Failed to get result from Debrid", "error", resp.Error())
 		return nil, resp.Error().(error)
 	}
 
@@ -97,8 +130,8 @@ func (rd *RealDebrid) GetFiles(infoHashs []string) (map[string][]*File, error) {
 	return files, nil
 }
 
-func (rd *RealDebrid) GetDownloadByInfoHash(infoHash string, fileID string) (string, error) {
-	download, err := rd.getDownloadByInfoHash(infoHash, fileID)
+func (rd *RealDebrid) GetDownloadByInfoHash(ctx context.Context, infoHash string, fileID string) (string, error) {
+	download, err := rd.getDownloadByInfoHash(ctx, infoHash, fileID)
 	if err == nil {
 		return download, nil
 	}
@@ -108,21 +141,21 @@ func (rd *RealDebrid) GetDownloadByInfoHash(infoHash string, fileID string) (str
 	}
 
 	magnetURI := "magnet:?xt=urn:btih:" + infoHash
-	torrentID, err := rd.addMagnet(magnetURI)
+	torrentID, err := rd.addMagnet(ctx, magnetURI)
 	if err != nil {
 		return "", err
 	}
 
-	torrent, err := rd.getTorrent(torrentID)
+	torrent, err := rd.getTorrent(ctx, torrentID)
 	if err != nil {
 		return "", err
 	}
 
-	return rd.getDownload(torrent, fileID)
+	return rd.getDownload(ctx, torrent, fileID)
 }
 
-func (rd *RealDebrid) GetDownloadByMagnetURI(infoHash string, magnetURI string, fileID string) (string, error) {
-	download, err := rd.getDownloadByInfoHash(infoHash, fileID)
+func (rd *RealDebrid) GetDownloadByMagnetURI(ctx context.Context, infoHash string, magnetURI string, fileID string) (string, error) {
+	download, err := rd.getDownloadByInfoHash(ctx, infoHash, fileID)
 	if err == nil {
 		return download, nil
 	}
@@ -131,28 +164,66 @@ func (rd *RealDebrid) GetDownloadByMagnetURI(infoHash string, magnetURI string,
 		return "", err
 	}
 
-	torrentID, err := rd.addMagnet(magnetURI)
+	torrentID, err := rd.addMagnet(ctx, magnetURI)
 	if err != nil {
 		return "", err
 	}
 
-	torrent, err := rd.getTorrent(torrentID)
+	torrent, err := rd.getTorrent(ctx, torrentID)
 	if err != nil {
 		return "", err
 	}
 
-	return rd.getDownload(torrent, fileID)
+	return rd.getDownload(ctx, torrent, fileID)
 }
 
-func (rd *RealDebrid) getDownloadByInfoHash(infoHash, fileID string) (string, error) {
-	torrents, err := rd.getTorrents()
+// RequestDownload adds the magnet with all its files selected, so uncached
+// torrents start downloading right away, and returns the torrent id.
+func (rd *RealDebrid) RequestDownload(ctx context.Context, magnetURI string) (string, error) {
+	torrentID, err := rd.addMagnet(ctx, magnetURI)
+	if err != nil {
+		return "", err
+	}
+
+	if err := rd.selectFileToDownload(ctx, torrentID); err != nil {
+		return "", err
+	}
+
+	return torrentID, nil
+}
+
+func (rd *RealDebrid) GetTorrent(ctx context.Context, torrentID string) (*Torrent, error) {
+	return rd.getTorrent(ctx, torrentID)
+}
+
+// GetUser returns the account of the token, failing when the token is no
+// longer valid.
+func (rd *RealDebrid) GetUser(ctx context.Context) (*User, error) {
+	result := &User{}
+	resp, err := rd.client.R().
+		SetContext(ctx).
+		SetResult(result).
+		Get("/user")
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.IsError() {
+		return nil, resp.Error().(error)
+	}
+
+	return result, nil
+}
+
+func (rd *RealDebrid) getDownloadByInfoHash(ctx context.Context, infoHash, fileID string) (string, error) {
+	torrents, err := rd.getTorrents(ctx)
 	if err != nil {
 		return "", err
 	}
 
 	for _, torrent := range torrents {
 		if torrent.Hash == infoHash {
-			download, err := rd.getDownload(&torrent, fileID)
+			download, err := rd.getDownload(ctx, &torrent, fileID)
 			if err == nil {
 				return download, err
 			}
@@ -166,9 +237,10 @@ func (rd *RealDebrid) getDownloadByInfoHash(infoHash, fileID string) (string, er
 	return "", ErrNoTorrentFound
 }
 
-func (rd *RealDebrid) addMagnet(magnetUri string) (string, error) {
+func (rd *RealDebrid) addMagnet(ctx context.Context, magnetUri string) (string, error) {
 	result := &AddMagnetResponse{}
 	resp, err := rd.client.R().
+		SetContext(ctx).
 		SetFormData(map[string]string{
 			"magnet": magnetUri,
 		}).
@@ -176,74 +248,76 @@ func (rd *RealDebrid) addMagnet(magnetUri string) (string, error) {
 		Post("/torrents/addMagnet")
 
 	if err != nil {
-		log.Errorf("Failed to select files on Debrid, err: %v", err)
+		slog.ErrorContext(ctx, "Failed to select files on Debrid", "error", err)
 		return "", err
 	}
 
 	if resp.IsError() {
-		log.Errorf("Failed to get result from Debrid, err: %v", resp.Error())
+		slog.ErrorContext(ctx, "Failed to get result from Debrid", "error", resp.Error())
 		return "", resp.Error().(error)
 	}
 
 	return result.ID, nil
 }
 
-func (rd *RealDebrid) getTorrent(torrentID string) (*Torrent, error) {
+func (rd *RealDebrid) getTorrent(ctx context.Context, torrentID string) (*Torrent, error) {
 	result := &Torrent{}
 	resp, err := rd.client.R().
+		SetContext(ctx).
 		SetResult(result).
 		Get("/torrents/info/" + torrentID)
 
 	if err != nil {
-		log.Errorf("Failed to fetch all torrents: %v", err)
+		slog.ErrorContext(ctx, "Failed to fetch torrents from Debrid", "error", err)
 		return nil, err
 	}
 
 	if resp.IsError() {
-		log.Errorf("Failed to get result from Debrid, err: %v", resp.Error())
+		slog.ErrorContext(ctx, "Failed to get result from Debrid", "error", resp.Error())
 		return nil, resp.Error().(error)
 	}
 
 	return result, nil
 }
 
-func (rd *RealDebrid) getTorrents() ([]Torrent, error) {
+func (rd *RealDebrid) getTorrents(ctx context.Context) ([]Torrent, error) {
 	result := []Torrent{}
 	resp, err := rd.client.R().
+		SetContext(ctx).
 		SetResult(&result).
 		SetQueryParam("limit", "200").
 		SetQueryParam("filter", "active").
 		Get("/torrents")
 
 	if err != nil {
-		log.Errorf("Failed to fetch all torrents: %v", err)
+		slog.ErrorContext(ctx, "Failed to fetch torrents from Debrid", "error", err)
 		return nil, err
 	}
 
 	if resp.IsError() {
-		log.Errorf("Failed to get torrents from Debrid, err: %v", resp.Error())
+		slog.ErrorContext(ctx, "Failed to get torrents from Debrid", "error", resp.Error())
 		return nil, resp.Error().(error)
 	}
 
 	return result, nil
 }
 
-func (rd *RealDebrid) getDownload(torrent *Torrent, fileID string) (string, error) {
+func (rd *RealDebrid) getDownload(ctx context.Context, torrent *Torrent, fileID string) (string, error) {
 	linkIndex := getIndexOfLinkForFile(torrent, fileID)
 	if torrent.Status == "waiting_files_selection" || linkIndex == -1 {
-		err := rd.selectFileToDownload(torrent.ID)
+		err := rd.selectFileToDownload(ctx, torrent.ID)
 		if err != nil {
 			return "", err
 		}
 
-		torrent, err = rd.getTorrent(torrent.ID)
+		torrent, err = rd.getTorrent(ctx, torrent.ID)
 		if err != nil {
 			return "", err
 		}
 	}
 
 	if torrent.Status != "downloaded" {
-		log.Infof("Torrent status is still %s", torrent.Status)
+		slog.InfoContext(ctx, "Torrent is not downloaded yet", "status", torrent.Status, "progress", torrent.Progress)
 		return "", ErrTorrentNotReady
 	}
 
@@ -253,11 +327,11 @@ func (rd *RealDebrid) getDownload(torrent *Torrent, fileID string) (string, erro
 	}
 
 	if len(torrent.Links) == 0 || len(torrent.Links) <= linkIndex {
-		log.Infof("Invalid torrent link: %d, len: %d", linkIndex, len(torrent.Links))
+		slog.InfoContext(ctx, "Invalid torrent link", "index", linkIndex, "links", len(torrent.Links))
 		return "", errors.New("not supported")
 	}
 
-	download, err := rd.generateDownload(torrent.Links[linkIndex])
+	download, err := rd.generateDownload(ctx, torrent.Links[linkIndex])
 	if err != nil {
 		return "", err
 	}
@@ -265,43 +339,43 @@ func (rd *RealDebrid) getDownload(torrent *Torrent, fileID string) (string, erro
 	return download, nil
 }
 
-func (rd *RealDebrid) generateDownload(hosterLink string) (string, error) {
+func (rd *RealDebrid) generateDownload(ctx context.Context, hosterLink string) (string, error) {
 	result := &UnrestrictedLinkResp{}
 	resp, err := rd.client.R().
+		SetContext(ctx).
 		SetResult(&result).
-		SetDebug(true).
 		SetFormData(map[string]string{
 			"link": hosterLink,
 		}).
 		Post("/unrestrict/link")
 
 	if err != nil {
-		log.Errorf("Failed to generate unrestricted link: %v", err)
+		slog.ErrorContext(ctx, "Failed to generate unrestricted link", "error", err)
 		return "", err
 	}
 
 	if resp.IsError() {
-		log.Errorf("Failed to generate download link from Debrid, err: %v", resp.Error())
+		slog.ErrorContext(ctx, "Failed to generate download link from Debrid", "error", resp.Error())
 		return "", resp.Error().(error)
 	}
 
 	return result.Download, nil
 }
 
-func (rd *RealDebrid) selectFileToDownload(torrentID string) error {
+func (rd *RealDebrid) selectFileToDownload(ctx context.Context, torrentID string) error {
 	resp, err := rd.client.R().
-		SetDebug(true).
+		SetContext(ctx).
 		SetFormData(map[string]string{
 			"files": "all",
 		}).
 		Post("/torrents/selectFiles/" + torrentID)
 	if err != nil {
-		log.Errorf("Failed to select files on Debrid, err: %v", err)
+		slog.ErrorContext(ctx, "Failed to select files on Debrid", "error", err)
 		return err
 	}
 
 	if resp.IsError() {
-		log.Errorf("Failed to select files from Debrid, err: %v", resp.Error())
+		slog.ErrorContext(ctx, "Failed to select files on Debrid", "error", resp.Error())
 		return resp.Error().(error)
 	}
 
@@ -338,6 +412,39 @@ type Torrent struct {
 	Links       []string      `json:"links"`
 }
 
+// Downloaded reports whether the torrent is ready to be streamed.
+func (t *Torrent) Downloaded() bool {
+	return t.Status == "downloaded"
+}
+
+// Failed reports whether the torrent will never finish downloading.
+func (t *Torrent) Failed() bool {
+	switch t.Status {
+	case "magnet_error", "error", "virus", "dead":
+		return true
+	default:
+		return false
+	}
+}
+
+// SelectedFiles returns the files of the torrent that are downloaded.
+func (t *Torrent) SelectedFiles() []*File {
+	files := make([]*File, 0, len(t.Files))
+	for _, f := range t.Files {
+		if f.Selected == 0 {
+			continue
+		}
+
+		files = append(files, &File{
+			ID:       fmt.Sprint(f.ID),
+			FileName: f.Path,
+			FileSize: uint64(f.Bytes),
+		})
+	}
+
+	return files
+}
+
 type TorrentFile struct {
 	ID       int    `json:"id"`
 	Path     string `json:"path"`
@@ -349,6 +456,13 @@ type UnrestrictedLinkResp struct {
 	Download string `json:"download"`
 }
 
+type User struct {
+	ID         int    `json:"id"`
+	Username   string `json:"username"`
+	Type       string `json:"type"`
+	Expiration string `json:"expiration"`
+}
+
 type ErrorResponse struct {
 	ErrTxt    string `json:"error"`
 	ErrorCode int    `json:"error_code"`
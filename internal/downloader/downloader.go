@@ -0,0 +1,49 @@
+// Package downloader hands torrents over to a download client running next to
+// StreamX, for results that are better downloaded than streamed.
+package downloader
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	TypeQBittorrent  = "qbittorrent"
+	TypeTransmission = "transmission"
+)
+
+var ErrRejected = errors.New("downloader: torrent rejected by the client")
+
+// Client adds torrents to a download client.
+type Client interface {
+	// Name is the client name shown to users.
+	Name() string
+	AddMagnet(magnetURI string) error
+}
+
+type Config struct {
+	Type     string
+	URL      string
+	Username string
+	Password string
+	// Category is the qBittorrent category or Transmission label of added torrents.
+	Category string
+	SavePath string
+}
+
+// New returns the client matching cfg.Type.
+func New(cfg Config) (Client, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("downloader: url is required")
+	}
+
+	switch strings.ToLower(cfg.Type) {
+	case TypeQBittorrent:
+		return NewQBittorrent(cfg), nil
+	case TypeTransmission:
+		return NewTransmission(cfg), nil
+	default:
+		return nil, fmt.Errorf("downloader: unsupported client %q", cfg.Type)
+	}
+}
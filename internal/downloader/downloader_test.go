@@ -0,0 +1,122 @@
+package downloader_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dbytex91/streamx/internal/downloader"
+)
+
+const magnetURI = "magnet:?xt=urn:btih:0123456789abcdef0123456789abcdef01234567&dn=Show.S01E01"
+
+func TestQBittorrentAddMagnet(t *testing.T) {
+	added := map[string]string{}
+	logins := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/auth/login":
+			logins++
+			if r.FormValue("username") != "admin" || r.FormValue("password") != "secret" {
+				w.Write([]byte("Fails."))
+				return
+			}
+			http.SetCookie(w, &http.Cookie{Name: "SID", Value: "session", Path: "/"})
+			w.Write([]byte("Ok."))
+		case "/api/v2/torrents/add":
+			if cookie, err := r.Cookie("SID"); err != nil || cookie.Value != "session" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			if !assert.NoError(t, r.ParseMultipartForm(1<<20)) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			for _, key := range []string{"urls", "category", "savepath"} {
+				added[key] = r.FormValue(key)
+			}
+			w.Write([]byte("Ok."))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client, err := downloader.New(downloader.Config{
+		Type:     downloader.TypeQBittorrent,
+		URL:      server.URL,
+		Username: "admin",
+		Password: "secret",
+		Category: "streamx",
+		SavePath: "/downloads/tv",
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, client.AddMagnet(magnetURI))
+	require.NoError(t, client.AddMagnet(magnetURI))
+	assert.Equal(t, 1, logins)
+	assert.Equal(t, map[string]string{"urls": magnetURI, "category": "streamx", "savepath": "/downloads/tv"}, added)
+
+	wrong, err := downloader.New(downloader.Config{Type: downloader.TypeQBittorrent, URL: server.URL, Username: "admin"})
+	require.NoError(t, err)
+	assert.Error(t, wrong.AddMagnet(magnetURI))
+}
+
+func TestTransmissionAddMagnet(t *testing.T) {
+	var arguments map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !assert.Equal(t, "/transmission/rpc", r.URL.Path) {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("X-Transmission-Session-Id") != "csrf" {
+			w.Header().Set("X-Transmission-Session-Id", "csrf")
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+
+		user, password, _ := r.BasicAuth()
+		if !assert.Equal(t, "admin:secret", user+":"+password) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		req := struct {
+			Method    string         `json:"method"`
+			Arguments map[string]any `json:"arguments"`
+		}{}
+		if !assert.NoError(t, json.NewDecoder(r.Body).Decode(&req)) || !assert.Equal(t, "torrent-add", req.Method) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		arguments = req.Arguments
+		w.Write([]byte(`{"result": "success", "arguments": {"torrent-added": {"id": 1}}}`))
+	}))
+	defer server.Close()
+
+	client, err := downloader.New(downloader.Config{
+		Type:     downloader.TypeTransmission,
+		URL:      server.URL,
+		Username: "admin",
+		Password: "secret",
+		Category: "streamx",
+		SavePath: "/downloads/tv",
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, client.AddMagnet(magnetURI))
+	assert.Equal(t, map[string]any{
+		"filename":     magnetURI,
+		"download-dir": "/downloads/tv",
+		"labels":       []any{"streamx"},
+	}, arguments)
+}
+
+func TestNewRejectsUnknownClient(t *testing.T) {
+	_, err := downloader.New(downloader.Config{Type: "deluge", URL: "http://localhost:8112"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,97 @@
+package downloader
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// QBittorrent talks to the qBittorrent Web API v2.
+type QBittorrent struct {
+	client *resty.Client
+	cfg    Config
+	mu     sync.Mutex
+	logged bool
+}
+
+func NewQBittorrent(cfg Config) *QBittorrent {
+	return &QBittorrent{
+		// resty keeps the SID cookie of the login in its cookie jar
+		client: resty.New().
+			SetBaseURL(strings.TrimRight(cfg.URL, "/")).
+			SetHeader("Referer", cfg.URL),
+		cfg: cfg,
+	}
+}
+
+func (q *QBittorrent) Name() string {
+	return "qBittorrent"
+}
+
+func (q *QBittorrent) AddMagnet(magnetURI string) error {
+	if err := q.login(false); err != nil {
+		return err
+	}
+
+	resp, err := q.add(magnetURI)
+	if err == nil && resp.StatusCode() == http.StatusForbidden {
+		// the session expired
+		if err := q.login(true); err != nil {
+			return err
+		}
+		resp, err = q.add(magnetURI)
+	}
+	if err != nil {
+		return err
+	}
+
+	if resp.IsError() {
+		return fmt.Errorf("error response from qbittorrent: %s", resp.Status())
+	}
+
+	if strings.TrimSpace(resp.String()) == "Fails." {
+		return ErrRejected
+	}
+
+	return nil
+}
+
+func (q *QBittorrent) add(magnetURI string) (*resty.Response, error) {
+	form := map[string]string{"urls": magnetURI}
+	if q.cfg.Category != "" {
+		form["category"] = q.cfg.Category
+	}
+	if q.cfg.SavePath != "" {
+		form["savepath"] = q.cfg.SavePath
+	}
+
+	return q.client.R().SetMultipartFormData(form).Post("/api/v2/torrents/add")
+}
+
+func (q *QBittorrent) login(force bool) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.logged && !force {
+		return nil
+	}
+
+	resp, err := q.client.R().
+		SetFormData(map[string]string{
+			"username": q.cfg.Username,
+			"password": q.cfg.Password,
+		}).
+		Post("/api/v2/auth/login")
+	if err != nil {
+		return err
+	}
+
+	if resp.IsError() || strings.TrimSpace(resp.String()) != "Ok." {
+		return fmt.Errorf("qbittorrent login failed: %s", resp.Status())
+	}
+
+	q.logged = true
+	return nil
+}
@@ -0,0 +1,92 @@
+package downloader
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const sessionIDHeader = "X-Transmission-Session-Id"
+
+// Transmission talks to the Transmission RPC API.
+type Transmission struct {
+	client    *resty.Client
+	cfg       Config
+	mu        sync.Mutex
+	sessionID string
+}
+
+type rpcRequest struct {
+	Method    string         `json:"method"`
+	Arguments map[string]any `json:"arguments"`
+}
+
+type rpcResponse struct {
+	Result string `json:"result"`
+}
+
+func NewTransmission(cfg Config) *Transmission {
+	client := resty.New().SetBaseURL(strings.TrimRight(cfg.URL, "/"))
+	if cfg.Username != "" {
+		client.SetBasicAuth(cfg.Username, cfg.Password)
+	}
+
+	return &Transmission{
+		client: client,
+		cfg:    cfg,
+	}
+}
+
+func (t *Transmission) Name() string {
+	return "Transmission"
+}
+
+func (t *Transmission) AddMagnet(magnetURI string) error {
+	arguments := map[string]any{"filename": magnetURI}
+	if t.cfg.SavePath != "" {
+		arguments["download-dir"] = t.cfg.SavePath
+	}
+	if t.cfg.Category != "" {
+		arguments["labels"] = []string{t.cfg.Category}
+	}
+
+	req := rpcRequest{Method: "torrent-add", Arguments: arguments}
+	resp, err := t.call(req)
+	if err == nil && resp.StatusCode() == http.StatusConflict {
+		// Transmission hands out the CSRF session id with a 409
+		t.mu.Lock()
+		t.sessionID = resp.Header().Get(sessionIDHeader)
+		t.mu.Unlock()
+		resp, err = t.call(req)
+	}
+	if err != nil {
+		return err
+	}
+
+	if resp.IsError() {
+		return fmt.Errorf("error response from transmission: %s", resp.Status())
+	}
+
+	if result := resp.Result().(*rpcResponse).Result; result != "success" {
+		return fmt.Errorf("%w: %s", ErrRejected, result)
+	}
+
+	return nil
+}
+
+func (t *Transmission) call(req rpcRequest) (*resty.Response, error) {
+	t.mu.Lock()
+	sessionID := t.sessionID
+	t.mu.Unlock()
+
+	return t.client.R().
+		SetHeader(sessionIDHeader, sessionID).
+		SetBody(req).
+		SetResult(&rpcResponse{}).
+		// older versions answer with text/plain
+		ForceContentType("application/json").
+		Post("/transmission/rpc")
+}
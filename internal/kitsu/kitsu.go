@@ -0,0 +1,278 @@
+package kitsu
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dbytex91/streamx/internal/metadata"
+	"github.com/dbytex91/streamx/internal/model"
+	"github.com/dbytex91/streamx/internal/tracing"
+	"github.com/go-resty/resty/v2"
+)
+
+const (
+	defaultBaseURL = "https://kitsu.io/api/edge"
+
+	PrefixKitsu = "kitsu:"
+	PrefixMAL   = "mal:"
+
+	malMappingSite = "myanimelist/anime"
+
+	// maxPrequels bounds the prequel chain followed to number episodes
+	// across a franchise.
+	maxPrequels = 10
+)
+
+// Kitsu resolves the kitsu: and mal: ids used by anime catalogs. Kitsu numbers
+// episodes per entry, which is what fansub releases use as absolute numbering.
+type Kitsu struct {
+	client *resty.Client
+}
+
+type animeResponse struct {
+	Data struct {
+		ID         string `json:"id"`
+		Attributes struct {
+			CanonicalTitle    string            `json:"canonicalTitle"`
+			Titles            map[string]string `json:"titles"`
+			AbbreviatedTitles []string          `json:"abbreviatedTitles"`
+			StartDate         string            `json:"startDate"`
+			EndDate           string            `json:"endDate"`
+			EpisodeCount      int               `json:"episodeCount"`
+			EpisodeLength     int               `json:"episodeLength"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+type relationshipsResponse struct {
+	Data []struct {
+		Attributes struct {
+			Role string `json:"role"`
+		} `json:"attributes"`
+		Relationships struct {
+			Destination struct {
+				Data struct {
+					ID   string `json:"id"`
+					Type string `json:"type"`
+				} `json:"data"`
+			} `json:"destination"`
+		} `json:"relationships"`
+	} `json:"data"`
+	Included []struct {
+		ID         string `json:"id"`
+		Type       string `json:"type"`
+		Attributes struct {
+			Subtype      string `json:"subtype"`
+			EpisodeCount int    `json:"episodeCount"`
+		} `json:"attributes"`
+	} `json:"included"`
+}
+
+type mappingsResponse struct {
+	Included []struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+	} `json:"included"`
+}
+
+func New() *Kitsu {
+	return NewWithBaseURL(defaultBaseURL)
+}
+
+func NewWithBaseURL(baseURL string) *Kitsu {
+	return &Kitsu{
+		client: tracing.InstrumentResty(resty.New().
+			SetBaseURL(baseURL).
+			SetHeader("Accept", "application/vnd.api+json"), "kitsu"),
+	}
+}
+
+// IsAnimeID tells whether the id is handled by Kitsu rather than by IMDb based providers.
+func IsAnimeID(id string) bool {
+	return strings.HasPrefix(id, PrefixKitsu) || strings.HasPrefix(id, PrefixMAL)
+}
+
+func (k *Kitsu) GetMovieById(ctx context.Context, id string) (*model.MetaInfo, error) {
+	return k.getAnime(ctx, id)
+}
+
+func (k *Kitsu) GetSeriesById(ctx context.Context, id string) (*model.MetaInfo, error) {
+	return k.getAnime(ctx, id)
+}
+
+func (k *Kitsu) getAnime(ctx context.Context, id string) (*model.MetaInfo, error) {
+	kitsuID, err := k.resolveID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &animeResponse{}
+	resp, err := k.client.R().SetContext(ctx).SetResult(result).Get("/anime/" + kitsuID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkResponse(resp); err != nil {
+		return nil, err
+	}
+
+	attributes := result.Data.Attributes
+	name := attributes.Titles["en"]
+	if name == "" {
+		name = attributes.CanonicalTitle
+	}
+
+	if name == "" {
+		return nil, metadata.ErrNotFound
+	}
+
+	alternatives := []string{attributes.CanonicalTitle, attributes.Titles["en_jp"], attributes.Titles["en_us"]}
+	alternatives = append(alternatives, attributes.AbbreviatedTitles...)
+
+	episodes := make([]model.Episode, 0, attributes.EpisodeCount)
+	for episode := 1; episode <= attributes.EpisodeCount; episode++ {
+		episodes = append(episodes, model.Episode{
+			Season:  1,
+			Episode: episode,
+			Runtime: attributes.EpisodeLength,
+		})
+	}
+
+	fromYear := parseYear(attributes.StartDate)
+	toYear := parseYear(attributes.EndDate)
+	if toYear == 0 {
+		toYear = fromYear
+	}
+
+	// sequels are numbered from 1 on Kitsu, an unknown offset only loses the
+	// releases numbered across the franchise
+	offset, _ := k.episodeOffset(ctx, kitsuID)
+
+	return &model.MetaInfo{
+		Name:             name,
+		OriginalName:     attributes.Titles["ja_jp"],
+		AlternativeNames: alternatives,
+		FromYear:         fromYear,
+		ToYear:           toYear,
+		Runtime:          attributes.EpisodeLength,
+		Episodes:         episodes,
+		EpisodeOffset:    offset,
+	}, nil
+}
+
+// episodeOffset counts the episodes of the TV prequels of the entry, e.g. the
+// first episode of a third season listed as its own entry is the offset + 1
+// in releases numbering episodes across the franchise.
+func (k *Kitsu) episodeOffset(ctx context.Context, kitsuID string) (int, error) {
+	offset := 0
+	seen := map[string]bool{kitsuID: true}
+	for range maxPrequels {
+		prequelID, episodes, err := k.tvPrequel(ctx, kitsuID)
+		if err != nil || prequelID == "" || seen[prequelID] {
+			return offset, err
+		}
+
+		seen[prequelID] = true
+		offset += episodes
+		kitsuID = prequelID
+	}
+
+	return offset, nil
+}
+
+// tvPrequel returns the TV series the entry follows and its episode count,
+// the id is empty when there's none.
+func (k *Kitsu) tvPrequel(ctx context.Context, kitsuID string) (string, int, error) {
+	result := &relationshipsResponse{}
+	resp, err := k.client.R().
+		SetContext(ctx).
+		SetQueryParam("include", "destination").
+		SetResult(result).
+		Get("/anime/" + kitsuID + "/media-relationships")
+	if err != nil {
+		return "", 0, err
+	}
+
+	if err := checkResponse(resp); err != nil {
+		return "", 0, err
+	}
+
+	for _, relationship := range result.Data {
+		destination := relationship.Relationships.Destination.Data
+		if relationship.Attributes.Role != "prequel" || destination.Type != "anime" {
+			continue
+		}
+
+		for _, included := range result.Included {
+			if included.Type == "anime" && included.ID == destination.ID && included.Attributes.Subtype == "TV" {
+				return included.ID, included.Attributes.EpisodeCount, nil
+			}
+		}
+	}
+
+	return "", 0, nil
+}
+
+// resolveID turns "kitsu:1234" or "mal:5678" into a Kitsu anime id.
+func (k *Kitsu) resolveID(ctx context.Context, id string) (string, error) {
+	switch {
+	case strings.HasPrefix(id, PrefixKitsu):
+		return strings.TrimPrefix(id, PrefixKitsu), nil
+	case strings.HasPrefix(id, PrefixMAL):
+		return k.findByMAL(ctx, strings.TrimPrefix(id, PrefixMAL))
+	default:
+		return "", fmt.Errorf("kitsu: unsupported id %s", id)
+	}
+}
+
+func (k *Kitsu) findByMAL(ctx context.Context, malID string) (string, error) {
+	if _, err := strconv.Atoi(malID); err != nil {
+		return "", fmt.Errorf("kitsu: invalid mal id %s", malID)
+	}
+
+	result := &mappingsResponse{}
+	resp, err := k.client.R().
+		SetContext(ctx).
+		SetQueryParams(map[string]string{
+			"filter[externalSite]": malMappingSite,
+			"filter[externalId]":   malID,
+			"include":              "item",
+		}).
+		SetResult(result).
+		Get("/mappings")
+	if err != nil {
+		return "", err
+	}
+
+	if err := checkResponse(resp); err != nil {
+		return "", err
+	}
+
+	for _, included := range result.Included {
+		if included.Type == "anime" {
+			return included.ID, nil
+		}
+	}
+
+	return "", metadata.ErrNotFound
+}
+
+func checkResponse(resp *resty.Response) error {
+	if resp.StatusCode() == http.StatusNotFound {
+		return metadata.ErrNotFound
+	}
+
+	if resp.IsError() {
+		return fmt.Errorf("error response from kitsu: %s", resp.Status())
+	}
+
+	return nil
+}
+
+func parseYear(date string) int {
+	year, _ := strconv.Atoi(strings.SplitN(date, "-", 2)[0])
+	return year
+}
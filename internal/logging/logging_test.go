@@ -0,0 +1,42 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dbytex91/streamx/internal/logging"
+)
+
+func TestRedaction(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger := logging.New(out, logging.Config{Level: "debug", Format: "json"})
+
+	logger.Info("Fetching http://prowlarr:9696/1/download?apikey=s3cr3t&link=abc",
+		"pKey", "prowlarr-key",
+		"Authorization", "Bearer rd-token",
+		"error", errors.New(`request failed: Authorization: Bearer rd-token`),
+		"body", `{"rd":"rd-key","minRes":"720"}`)
+
+	logged := out.String()
+	for _, secret := range []string{"s3cr3t", "prowlarr-key", "rd-token", "rd-key"} {
+		assert.NotContains(t, logged, secret)
+	}
+	assert.Contains(t, logged, "link=abc")
+	assert.Contains(t, logged, `\"minRes\":\"720\"`)
+}
+
+func TestRequestID(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger := logging.New(out, logging.Config{Format: "json"})
+
+	ctx := logging.WithRequestID(context.Background(), "abc123")
+	logger.InfoContext(ctx, "Completed search")
+	logger.DebugContext(ctx, "Hidden below the info level")
+
+	assert.Contains(t, out.String(), `"request_id":"abc123"`)
+	assert.NotContains(t, out.String(), "Hidden")
+}
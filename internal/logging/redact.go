@@ -0,0 +1,71 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+var (
+	// secretKeys are attribute keys and headers whose values are never logged.
+	secretKeys = map[string]bool{
+		"apikey":         true,
+		"api_key":        true,
+		"pkey":           true,
+		"rd":             true,
+		"rdapikey":       true,
+		"prowlarrapikey": true,
+		"sendkey":        true,
+		"token":          true,
+		"access_token":   true,
+		"refresh_token":  true,
+		"client_secret":  true,
+		"password":       true,
+		"secret":         true,
+		"authorization":  true,
+		"x-api-key":      true,
+		"cookie":         true,
+		"set-cookie":     true,
+	}
+
+	// secretPatterns find secrets inside messages and values, e.g. the api key
+	// in the query of Prowlarr download links or bearer tokens in errors.
+	secretPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)((?:api_?key|apikey|token|access_token|refresh_token|client_secret|password|secret|pkey)=)[^&\s"]+`),
+		regexp.MustCompile(`(?i)((?:"(?:rd|pKey|sendKey|apiKey|api_key|token|access_token|refresh_token|client_secret|password)"\s*:\s*"))[^"]+`),
+		regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/=-]+`),
+		regexp.MustCompile(`(?i)(x-api-key:\s*)\S+`),
+	}
+)
+
+// Redact scrubs known secret patterns from s.
+func Redact(s string) string {
+	for _, pattern := range secretPatterns {
+		s = pattern.ReplaceAllString(s, "${1}"+redacted)
+	}
+
+	return s
+}
+
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	if secretKeys[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Redact(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, Redact(err.Error()))
+		}
+		if s, ok := a.Value.Any().(fmt.Stringer); ok {
+			return slog.String(a.Key, Redact(s.String()))
+		}
+	}
+
+	return a
+}
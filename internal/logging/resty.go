@@ -0,0 +1,22 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// RestyLogger routes the warnings of resty clients to slog, where secrets are
+// redacted.
+type RestyLogger struct{}
+
+func (RestyLogger) Errorf(format string, v ...any) {
+	slog.Error(fmt.Sprintf(format, v...), "component", "http")
+}
+
+func (RestyLogger) Warnf(format string, v ...any) {
+	slog.Warn(fmt.Sprintf(format, v...), "component", "http")
+}
+
+func (RestyLogger) Debugf(format string, v ...any) {
+	slog.Debug(fmt.Sprintf(format, v...), "component", "http")
+}
@@ -0,0 +1,63 @@
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/coocood/freecache"
+
+	"github.com/dbytex91/streamx/internal/model"
+)
+
+// Cached keeps provider answers in a shared freecache for expirySeconds.
+// Errors are not cached so a failing provider is retried on the next request.
+type Cached struct {
+	provider      Provider
+	cache         *freecache.Cache
+	expirySeconds int
+}
+
+func NewCached(provider Provider, cache *freecache.Cache, expirySeconds int) *Cached {
+	return &Cached{
+		provider:      provider,
+		cache:         cache,
+		expirySeconds: expirySeconds,
+	}
+}
+
+func (c *Cached) GetMovieById(ctx context.Context, id string) (*model.MetaInfo, error) {
+	return c.get(ctx, "meta:movie:"+id, func() (*model.MetaInfo, error) {
+		return c.provider.GetMovieById(ctx, id)
+	})
+}
+
+func (c *Cached) GetSeriesById(ctx context.Context, id string) (*model.MetaInfo, error) {
+	return c.get(ctx, "meta:series:"+id, func() (*model.MetaInfo, error) {
+		return c.provider.GetSeriesById(ctx, id)
+	})
+}
+
+func (c *Cached) get(ctx context.Context, key string, load func() (*model.MetaInfo, error)) (*model.MetaInfo, error) {
+	if raw, err := c.cache.Get([]byte(key)); err == nil {
+		metaInfo := &model.MetaInfo{}
+		if err := json.Unmarshal(raw, metaInfo); err == nil {
+			return metaInfo, nil
+		}
+	}
+
+	metaInfo, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := json.Marshal(metaInfo)
+	if err == nil {
+		err = c.cache.Set([]byte(key), raw, c.expirySeconds)
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "Couldn't cache metadata", "key", key, "error", err)
+	}
+
+	return metaInfo, nil
+}
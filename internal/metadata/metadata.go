@@ -0,0 +1,50 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dbytex91/streamx/internal/model"
+)
+
+var ErrNotFound = errors.New("metadata: title not found")
+
+// Provider looks up movies and series by their IMDb ID.
+type Provider interface {
+	GetMovieById(ctx context.Context, id string) (*model.MetaInfo, error)
+	GetSeriesById(ctx context.Context, id string) (*model.MetaInfo, error)
+}
+
+// Chain asks every provider in order and returns the first successful answer.
+type Chain []Provider
+
+func (c Chain) GetMovieById(ctx context.Context, id string) (*model.MetaInfo, error) {
+	return c.first(func(p Provider) (*model.MetaInfo, error) {
+		return p.GetMovieById(ctx, id)
+	})
+}
+
+func (c Chain) GetSeriesById(ctx context.Context, id string) (*model.MetaInfo, error) {
+	return c.first(func(p Provider) (*model.MetaInfo, error) {
+		return p.GetSeriesById(ctx, id)
+	})
+}
+
+func (c Chain) first(get func(Provider) (*model.MetaInfo, error)) (*model.MetaInfo, error) {
+	if len(c) == 0 {
+		return nil, errors.New("metadata: no provider configured")
+	}
+
+	errs := make([]error, 0, len(c))
+	for _, provider := range c {
+		metaInfo, err := get(provider)
+		if err == nil {
+			return metaInfo, nil
+		}
+
+		errs = append(errs, fmt.Errorf("%T: %w", provider, err))
+	}
+
+	return nil, errors.Join(errs...)
+}
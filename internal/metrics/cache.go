@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"log/slog"
+
+	"github.com/coocood/freecache"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// cacheCollector reads the statistics of a freecache cache when scraped.
+type cacheCollector struct {
+	cache *freecache.Cache
+
+	entries   *prometheus.Desc
+	hitRate   *prometheus.Desc
+	lookups   *prometheus.Desc
+	evictions *prometheus.Desc
+	expired   *prometheus.Desc
+}
+
+// RegisterCache exposes the statistics of cache under the given name.
+func RegisterCache(name string, cache *freecache.Cache) {
+	labels := prometheus.Labels{"cache": name}
+	collector := &cacheCollector{
+		cache:     cache,
+		entries:   prometheus.NewDesc(namespace+"_cache_entries", "Entries in the cache.", nil, labels),
+		hitRate:   prometheus.NewDesc(namespace+"_cache_hit_rate", "Ratio of cache lookups that were hits.", nil, labels),
+		lookups:   prometheus.NewDesc(namespace+"_cache_lookups_total", "Cache lookups by result.", []string{"result"}, labels),
+		evictions: prometheus.NewDesc(namespace+"_cache_evictions_total", "Entries evicted to make room for new ones.", nil, labels),
+		expired:   prometheus.NewDesc(namespace+"_cache_expired_total", "Entries removed after they expired.", nil, labels),
+	}
+
+	if err := Registry.Register(collector); err != nil {
+		slog.Warn("Couldn't register cache metrics", "cache", name, "error", err)
+	}
+}
+
+func (c *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.entries
+	ch <- c.hitRate
+	ch <- c.lookups
+	ch <- c.evictions
+	ch <- c.expired
+}
+
+func (c *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(c.entries, prometheus.GaugeValue, float64(c.cache.EntryCount()))
+	ch <- prometheus.MustNewConstMetric(c.hitRate, prometheus.GaugeValue, c.cache.HitRate())
+	ch <- prometheus.MustNewConstMetric(c.lookups, prometheus.CounterValue, float64(c.cache.HitCount()), "hit")
+	ch <- prometheus.MustNewConstMetric(c.lookups, prometheus.CounterValue, float64(c.cache.MissCount()), "miss")
+	ch <- prometheus.MustNewConstMetric(c.evictions, prometheus.CounterValue, float64(c.cache.EvacuateCount()))
+	ch <- prometheus.MustNewConstMetric(c.expired, prometheus.CounterValue, float64(c.cache.ExpiredCount()))
+}
@@ -0,0 +1,103 @@
+// Package metrics exposes Prometheus metrics of the server. Labels never
+// carry per-user data such as configurations, API keys or requested ids.
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "streamx"
+
+var (
+	Registry = prometheus.NewRegistry()
+
+	StreamRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "stream_requests_total",
+		Help:      "Stream requests by content type and result (ok, empty or error).",
+	}, []string{"type", "result"})
+
+	PipelineDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "pipeline_duration_seconds",
+		Help:      "Duration of the stream pipeline by content type.",
+		Buckets:   []float64{0.5, 1, 2.5, 5, 10, 20, 30, 45, 60, 90, 120},
+	}, []string{"type"})
+
+	IndexerSearchDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "indexer_search_duration_seconds",
+		Help:      "Duration of Prowlarr searches by indexer.",
+		Buckets:   []float64{0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 60},
+	}, []string{"indexer"})
+
+	IndexerSearchErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "indexer_search_errors_total",
+		Help:      "Failed Prowlarr searches by indexer.",
+	}, []string{"indexer"})
+
+	InfoHashCache = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "infohash_cache_requests_total",
+		Help:      "Info hash cache lookups by result (hit or miss).",
+	}, []string{"result"})
+
+	DebridRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "debrid_requests_total",
+		Help:      "Real-Debrid API calls by endpoint and status code, error when no response was received.",
+	}, []string{"endpoint", "status"})
+
+	DownloadRedirects = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "download_redirects_total",
+		Help:      "Download requests by result (redirect or error) and whether the link was cached.",
+	}, []string{"result", "cached"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		StreamRequests,
+		PipelineDuration,
+		IndexerSearchDuration,
+		IndexerSearchErrors,
+		InfoHashCache,
+		DebridRequests,
+		DownloadRedirects,
+	)
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.HandlerFor(Registry, promhttp.HandlerOpts{}))
+}
+
+// ObserveSearch records a Prowlarr search of indexer started at start, callers
+// pass a fixed value for indexers named by users.
+func ObserveSearch(indexer string, start time.Time, err error) {
+	IndexerSearchDuration.WithLabelValues(indexer).Observe(time.Since(start).Seconds())
+	if err != nil {
+		IndexerSearchErrors.WithLabelValues(indexer).Inc()
+	}
+}
+
+// ObserveDebrid records a Real-Debrid API call, status is 0 when no response
+// was received.
+func ObserveDebrid(endpoint string, status int) {
+	label := "error"
+	if status > 0 {
+		label = strconv.Itoa(status)
+	}
+
+	DebridRequests.WithLabelValues(endpoint, label).Inc()
+}
@@ -1,8 +1,65 @@
 package model
 
+import "strings"
+
 type MetaInfo struct {
-	Name     string
-	FromYear int
-	ToYear   int
-	IMDBID   uint
+	Name             string
+	OriginalName     string
+	AlternativeNames []string
+	FromYear         int
+	ToYear           int
+	IMDBID           uint
+	// Runtime is the length of the movie, or of a typical episode, in minutes.
+	Runtime  int
+	Episodes []Episode
+	// EpisodeOffset is the number of episodes aired before the first one of
+	// the entry, for anime sequels listed as their own entry.
+	EpisodeOffset int
+}
+
+type Episode struct {
+	Season  int
+	Episode int
+	Title   string
+	Runtime int
+}
+
+// Names returns the display name followed by the original and alternative
+// names, without duplicates.
+func (m *MetaInfo) Names() []string {
+	names := make([]string, 0, len(m.AlternativeNames)+2)
+	seen := map[string]bool{}
+	for _, name := range append([]string{m.Name, m.OriginalName}, m.AlternativeNames...) {
+		key := strings.ToLower(name)
+		if name == "" || seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// AbsoluteEpisode returns the position of the episode counted from the first
+// episode of the first season, as used by fansub releases. Specials (season 0)
+// are not counted. It returns 0 when the episode list doesn't contain it.
+func (m *MetaInfo) AbsoluteEpisode(season int, episode int) int {
+	absolute := 0
+	found := false
+	for _, e := range m.Episodes {
+		if e.Season < 1 || e.Season > season || (e.Season == season && e.Episode > episode) {
+			continue
+		}
+
+		absolute++
+		found = found || (e.Season == season && e.Episode == episode)
+	}
+
+	if !found {
+		return 0
+	}
+
+	return absolute
 }
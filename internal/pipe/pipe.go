@@ -1,12 +1,17 @@
 package pipe
 
-import "time"
+import (
+	"context"
+	"log/slog"
+	"time"
+)
 
 const (
 	defaultConcurrency = 5
 )
 
 type Pipe[R any] struct {
+	ctx     context.Context
 	source  Source[R]
 	stages  []pipeStage[R]
 	stopped chan struct{}
@@ -23,12 +28,22 @@ type pipeStage[R any] interface {
 
 func New[R any](source Source[R]) *Pipe[R] {
 	return &Pipe[R]{
+		ctx:     context.Background(),
 		source:  source,
 		errCh:   make(chan error, 1),
 		stopped: make(chan struct{}),
 	}
 }
 
+// SetContext sets the context the pipe logs with, e.g. to carry a request id.
+func (p *Pipe[R]) SetContext(ctx context.Context) {
+	p.ctx = ctx
+}
+
+func (p *Pipe[R]) Context() context.Context {
+	return p.ctx
+}
+
 func (p *Pipe[R]) Map(fn func(r *R) (*R, error), opts ...SimpleStageOption[R]) {
 	p.FanOut(func(in *R) ([]*R, error) {
 		out, err := fn(in)
@@ -70,11 +85,12 @@ func (p *Pipe[R]) SinkWithTimeout(sink Sink[R], timeout time.Duration) error {
 	}
 
 	p.startSink(sink, outCh)
-	
+
 	// Use custom timeout instead of p.timeoutCh
 	timeoutCh := time.After(timeout)
 	select {
 	case <-timeoutCh:
+		slog.WarnContext(p.ctx, "Pipe timed out", "timeout", timeout)
 		p.Stop()
 	case <-p.stopped:
 	}
@@ -106,6 +122,12 @@ func (p *Pipe[R]) Stop() {
 	}
 }
 
+// Done is closed once the pipe stopped, when the sink got every record, on
+// timeout or on the first error.
+func (p *Pipe[R]) Done() <-chan struct{} {
+	return p.stopped
+}
+
 func (p *Pipe[R]) Batch(fn func([]*R) ([]*R, error), opts ...BatchStageOption[R]) {
 	stage := &batchStage[R]{
 		fn:          fn,
@@ -123,7 +145,6 @@ func (p *Pipe[R]) Batch(fn func([]*R) ([]*R, error), opts ...BatchStageOption[R]
 	p.stages = append(p.stages, stage)
 }
 
-
 func (p *Pipe[R]) Filter(fn func(r *R) bool, opts ...SimpleStageOption[R]) {
 	p.FanOut(func(in *R) ([]*R, error) {
 		ok := fn(in)
@@ -163,6 +184,8 @@ func (p *Pipe[R]) startSink(sink Sink[R], inCh <-chan *R) {
 }
 
 func (p *Pipe[R]) reportError(err error) {
+	// only the first error stops the pipe, log them all
+	slog.DebugContext(p.ctx, "Pipe stage failed", "error", err)
 	select {
 	case <-p.stopped:
 	case p.errCh <- err:
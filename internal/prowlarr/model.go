@@ -38,6 +38,13 @@ type Torrent struct {
 	Subs      []string `json:"Subs"`
 	Peers     uint     `json:"Peers"`
 	Files     uint     `json:"files"`
+	// FileList holds the files of the torrent once its metadata is known,
+	// in torrent order so the index is the file index streaming clients use.
+	FileList []File `json:"-"`
+	// Trackers are the announce tiers from the magnet link or torrent file.
+	Trackers [][]string `json:"-"`
+	// Info is the parsed info dictionary, when the torrent file was fetched.
+	Info *Info `json:"-"`
 }
 
 type RSSItem struct {
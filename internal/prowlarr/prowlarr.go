@@ -2,20 +2,28 @@ package prowlarr
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha1"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"strconv"
 	"strings"
 
 	"github.com/go-resty/resty/v2"
-	"github.com/gofiber/fiber/v2/log"
+
+	"github.com/dbytex91/streamx/internal/logging"
+	"github.com/dbytex91/streamx/internal/tracing"
 )
 
+var ErrNoTorrentFile = errors.New("prowlarr: no torrent file available")
+
 const (
 	moviesCategory = "2000"
 	tvCategory     = "5000"
+	animeCategory  = "5070"
 )
 
 type Prowlarr struct {
@@ -25,10 +33,11 @@ type Prowlarr struct {
 
 func New(apiURL string, apiKey string) *Prowlarr {
 	client := resty.New().
-		// SetDebug(true).
 		SetBaseURL(apiURL).
+		SetLogger(logging.RestyLogger{}).
 		SetHeader("X-Api-Key", apiKey).
 		SetRedirectPolicy(NotFollowMagnet())
+	tracing.InstrumentResty(client, "prowlarr")
 
 	return &Prowlarr{
 		client: client,
@@ -36,10 +45,11 @@ func New(apiURL string, apiKey string) *Prowlarr {
 	}
 }
 
-func (j *Prowlarr) GetAllIndexers() ([]*Indexer, error) {
+func (j *Prowlarr) GetAllIndexers(ctx context.Context) ([]*Indexer, error) {
 	result := []*Indexer{}
 	resp, err := j.client.
 		R().
+		SetContext(ctx).
 		SetResult(&result).
 		Get("/api/v1/indexer")
 
@@ -54,10 +64,11 @@ func (j *Prowlarr) GetAllIndexers() ([]*Indexer, error) {
 	return result, nil
 }
 
-func (j *Prowlarr) SearchMovieTorrents(indexer *Indexer, name string) ([]*Torrent, error) {
+func (j *Prowlarr) SearchMovieTorrents(ctx context.Context, indexer *Indexer, name string) ([]*Torrent, error) {
 	result := []*Torrent{}
 	resp, err := j.client.
 		R().
+		SetContext(ctx).
 		SetQueryParam("query", name).
 		SetQueryParam("categories", moviesCategory).
 		SetQueryParam("type", "movie").
@@ -66,12 +77,12 @@ func (j *Prowlarr) SearchMovieTorrents(indexer *Indexer, name string) ([]*Torren
 		Get("/api/v1/search")
 
 	if err != nil {
-		log.Errorf("Failed to search for %v from %v: %v", name, indexer.Name, err)
+		slog.ErrorContext(ctx, "Failed to search", "query", name, "indexer", indexer.Name, "error", err)
 		return nil, err
 	}
 
 	if resp.IsError() {
-		log.Errorf("Failed to search for %v from %v: %v", name, indexer.Name, resp.Error())
+		slog.ErrorContext(ctx, "Failed to search", "query", name, "indexer", indexer.Name, "error", resp.Error())
 		return nil, fmt.Errorf("error response from prowlarr: %v", resp.Error())
 	}
 
@@ -84,10 +95,11 @@ func (j *Prowlarr) SearchMovieTorrents(indexer *Indexer, name string) ([]*Torren
 	return result, nil
 }
 
-func (j *Prowlarr) SearchSeasonTorrents(indexer *Indexer, name string, season int) ([]*Torrent, error) {
+func (j *Prowlarr) SearchSeasonTorrents(ctx context.Context, indexer *Indexer, name string, season int) ([]*Torrent, error) {
 	result := []*Torrent{}
 	resp, err := j.client.
 		R().
+		SetContext(ctx).
 		SetQueryParam("query", fmt.Sprintf("%s{Season:%02d}", name, season)).
 		SetQueryParam("categories", tvCategory).
 		SetQueryParam("type", "tvsearch").
@@ -96,12 +108,12 @@ func (j *Prowlarr) SearchSeasonTorrents(indexer *Indexer, name string, season in
 		Get("/api/v1/search")
 
 	if err != nil {
-		log.Errorf("Failed to search for %v from %v: %v", name, indexer.Name, err)
+		slog.ErrorContext(ctx, "Failed to search", "query", name, "indexer", indexer.Name, "error", err)
 		return nil, err
 	}
 
 	if resp.IsError() {
-		log.Errorf("Failed to search for %v from %v: %v", name, indexer.Name, resp.Error())
+		slog.ErrorContext(ctx, "Failed to search", "query", name, "indexer", indexer.Name, "error", resp.Error())
 		return nil, fmt.Errorf("error response from prowlarr: %v", resp.Error())
 	}
 
@@ -114,10 +126,11 @@ func (j *Prowlarr) SearchSeasonTorrents(indexer *Indexer, name string, season in
 	return result, nil
 }
 
-func (j *Prowlarr) SearchSeriesTorrents(indexer *Indexer, name string) ([]*Torrent, error) {
+func (j *Prowlarr) SearchSeriesTorrents(ctx context.Context, indexer *Indexer, name string) ([]*Torrent, error) {
 	result := []*Torrent{}
 	resp, err := j.client.
 		R().
+		SetContext(ctx).
 		SetQueryParam("query", name).
 		SetQueryParam("categories", tvCategory).
 		SetQueryParam("type", "tvsearch").
@@ -126,12 +139,43 @@ func (j *Prowlarr) SearchSeriesTorrents(indexer *Indexer, name string) ([]*Torre
 		Get("/api/v1/search")
 
 	if err != nil {
-		log.Errorf("Failed to search for %v from %v: %v", name, indexer.Name, err)
+		slog.ErrorContext(ctx, "Failed to search", "query", name, "indexer", indexer.Name, "error", err)
+		return nil, err
+	}
+
+	if resp.IsError() {
+		slog.ErrorContext(ctx, "Failed to search", "query", name, "indexer", indexer.Name, "error", resp.Error())
+		return nil, fmt.Errorf("error response from prowlarr: %v", resp.Error())
+	}
+
+	for _, torrent := range result {
+		normaliseTorrent(torrent, j.apiURL)
+	}
+
+	return result, nil
+}
+
+// SearchAnimeTorrents searches the TV/Anime category with a free text query,
+// anime indexers rarely support tvsearch parameters.
+func (j *Prowlarr) SearchAnimeTorrents(ctx context.Context, indexer *Indexer, name string) ([]*Torrent, error) {
+	result := []*Torrent{}
+	resp, err := j.client.
+		R().
+		SetContext(ctx).
+		SetQueryParam("query", name).
+		SetQueryParam("categories", animeCategory).
+		SetQueryParam("type", "search").
+		SetQueryParam("indexerIds", strconv.Itoa(indexer.ID)).
+		SetResult(&result).
+		Get("/api/v1/search")
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to search", "query", name, "indexer", indexer.Name, "error", err)
 		return nil, err
 	}
 
 	if resp.IsError() {
-		log.Errorf("Failed to search for %v from %v: %v", name, indexer.Name, resp.Error())
+		slog.ErrorContext(ctx, "Failed to search", "query", name, "indexer", indexer.Name, "error", resp.Error())
 		return nil, fmt.Errorf("error response from prowlarr: %v", resp.Error())
 	}
 
@@ -142,22 +186,25 @@ func (j *Prowlarr) SearchSeriesTorrents(indexer *Indexer, name string) ([]*Torre
 	return result, nil
 }
 
-func (j *Prowlarr) FetchInfoHash(torrent *Torrent) (*Torrent, error) {
+func (j *Prowlarr) FetchInfoHash(ctx context.Context, torrent *Torrent) (*Torrent, error) {
 	if torrent.InfoHash != "" {
+		if magnet, err := ParseMagnetUri(torrent.MagnetUri); err == nil && len(torrent.Trackers) == 0 {
+			torrent.Trackers = magnet.Trackers
+		}
 		return torrent, nil
 	}
 
 	if torrent.MagnetUri == "" {
-		resp, err := j.client.R().Get(torrent.Link)
+		resp, err := j.client.R().SetContext(ctx).Get(torrent.Link)
 		if err != nil {
-			log.Errorf("Failed to fetch magnet link for %s due to: %v", torrent.Link, err)
+			slog.ErrorContext(ctx, "Failed to fetch the magnet link", "link", torrent.Link, "error", err)
 			return torrent, err
 		}
 
 		if resp.Header().Get("Content-Type") == "application/x-bittorrent" {
 			torFile, err := parseTorrentFile(bytes.NewReader(resp.Body()))
 			if err != nil {
-				log.Errorf("Invalid torrent file for %s with: %v", torrent.Link, err)
+				slog.ErrorContext(ctx, "Invalid torrent file", "link", torrent.Link, "error", err)
 				return torrent, err
 			}
 
@@ -168,12 +215,15 @@ func (j *Prowlarr) FetchInfoHash(torrent *Torrent) (*Torrent, error) {
 			}
 			torrent.MagnetUri = magnet.String()
 			torrent.InfoHash = strings.ToLower(magnet.InfoHashStr())
+			torrent.FileList = torFile.Info.Files
+			torrent.Trackers = torFile.AnnounceList
+			torrent.Info = &torFile.Info
 		} else {
 			torrent.MagnetUri = resp.Header().Get("location")
 		}
 
 		if torrent.MagnetUri == "" {
-			log.Errorf("Unexpected magnet uri for %s, %s", torrent.Guid, torrent.Title)
+			slog.ErrorContext(ctx, "Unexpected magnet URI", "guid", torrent.Guid, "torrent", torrent.Title)
 			return torrent, errors.New("magnet uri is expected but not found")
 		}
 	}
@@ -183,10 +233,52 @@ func (j *Prowlarr) FetchInfoHash(torrent *Torrent) (*Torrent, error) {
 		return torrent, err
 	}
 	torrent.InfoHash = strings.ToLower(magnet.InfoHashStr())
+	if len(torrent.Trackers) == 0 {
+		torrent.Trackers = magnet.Trackers
+	}
 
 	return torrent, nil
 }
 
+// FetchFileList downloads the .torrent file of the torrent to read its file
+// list. Results that only have a magnet link return ErrNoTorrentFile.
+func (j *Prowlarr) FetchFileList(ctx context.Context, torrent *Torrent) error {
+	if len(torrent.FileList) > 0 {
+		return nil
+	}
+
+	if !strings.HasPrefix(torrent.Link, "http://") && !strings.HasPrefix(torrent.Link, "https://") {
+		return ErrNoTorrentFile
+	}
+
+	resp, err := j.client.R().SetContext(ctx).Get(torrent.Link)
+	if err != nil {
+		return err
+	}
+
+	if resp.Header().Get("Content-Type") != "application/x-bittorrent" {
+		// the link redirects to a magnet
+		return ErrNoTorrentFile
+	}
+
+	torFile, err := parseTorrentFile(bytes.NewReader(resp.Body()))
+	if err != nil {
+		return err
+	}
+
+	infoHash := hex.EncodeToString(torFile.Info.Hash[:])
+	if torrent.InfoHash != "" && torrent.InfoHash != infoHash {
+		return fmt.Errorf("torrent file of %s has info hash %s", torrent.InfoHash, infoHash)
+	}
+
+	torrent.FileList = torFile.Info.Files
+	torrent.Info = &torFile.Info
+	if len(torrent.Trackers) == 0 {
+		torrent.Trackers = torFile.AnnounceList
+	}
+	return nil
+}
+
 func generateGID(content string) []byte {
 	h := sha1.New()
 	io.WriteString(h, content)
@@ -206,7 +298,7 @@ func normaliseTorrent(tor *Torrent, prowlarURL string) {
 			// ThePirateBay has magnet link in Guid
 			tor.MagnetUri = tor.Guid
 		} else if tor.MagnetUri != "" {
-			log.Errorf("Invalid magnet URI %v", tor.MagnetUri)
+			slog.Error("Invalid magnet URI", "magnet", tor.MagnetUri)
 			tor.MagnetUri = ""
 		}
 	}
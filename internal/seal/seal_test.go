@@ -0,0 +1,65 @@
+package seal_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dbytex91/streamx/internal/seal"
+)
+
+const (
+	oldKey = "k1:MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+	newKey = "k2:ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA="
+)
+
+func TestSealRoundTrip(t *testing.T) {
+	keys, err := seal.ParseKeys([]string{oldKey})
+	require.NoError(t, err)
+
+	blob, err := keys.Seal([]byte(`{"pKey":"secret"}`))
+	require.NoError(t, err)
+	assert.True(t, seal.IsSealed(blob))
+	assert.NotContains(t, blob, "secret")
+
+	plaintext, err := keys.Open(blob)
+	require.NoError(t, err)
+	assert.Equal(t, `{"pKey":"secret"}`, string(plaintext))
+}
+
+func TestSealRotation(t *testing.T) {
+	old, err := seal.ParseKeys([]string{oldKey})
+	require.NoError(t, err)
+	blob, err := old.Seal([]byte("config"))
+	require.NoError(t, err)
+
+	rotated, err := seal.ParseKeys([]string{newKey, oldKey})
+	require.NoError(t, err)
+	plaintext, err := rotated.Open(blob)
+	require.NoError(t, err)
+	assert.Equal(t, "config", string(plaintext))
+
+	resealed, err := rotated.Seal(plaintext)
+	require.NoError(t, err)
+	_, err = old.Open(resealed)
+	assert.ErrorIs(t, err, seal.ErrInvalid)
+}
+
+func TestSealTampering(t *testing.T) {
+	keys, err := seal.ParseKeys([]string{oldKey})
+	require.NoError(t, err)
+	blob, err := keys.Seal([]byte("config"))
+	require.NoError(t, err)
+
+	tampered := []byte(blob)
+	tampered[len(tampered)-2] ^= 1
+	_, err = keys.Open(string(tampered))
+	assert.ErrorIs(t, err, seal.ErrInvalid)
+
+	_, err = keys.Open("v1.k9." + blob[len("v1.k1."):])
+	assert.ErrorIs(t, err, seal.ErrInvalid)
+
+	_, err = seal.ParseKeys([]string{"k1:c2hvcnQ="})
+	assert.Error(t, err)
+}
@@ -1,7 +1,7 @@
 package static
 
 import (
-	_ "embed"
+	"embed"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -9,8 +9,25 @@ import (
 //go:embed configure.html
 var configure []byte
 
+// videos are the clips played while a requested download isn't ready, they
+// are written by cmd/statusvideos.
+//
+//go:embed videos/*.mp4
+var videos embed.FS
+
 func HandleConfigure(c *fiber.Ctx) error {
 	c.Response().Header.Add("Cache-control", "max-age=86400, public")
 	c.Set(fiber.HeaderContentType, fiber.MIMETextHTML)
 	return c.Send(configure)
 }
+
+func HandleStatusVideo(c *fiber.Ctx) error {
+	video, err := videos.ReadFile("videos/" + c.Params("video"))
+	if err != nil {
+		return c.SendStatus(404)
+	}
+
+	c.Response().Header.Add("Cache-control", "max-age=86400, public")
+	c.Set(fiber.HeaderContentType, "video/mp4")
+	return c.Send(video)
+}
@@ -0,0 +1,112 @@
+// Package store keeps server-side state that must survive restarts in an
+// embedded database.
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var ErrNotFound = errors.New("store: not found")
+
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens the database at path, creating it when missing.
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Get decodes the JSON value of key into v.
+func (s *Store) Get(bucket, key string, v any) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return ErrNotFound
+		}
+
+		raw := b.Get([]byte(key))
+		if raw == nil {
+			return ErrNotFound
+		}
+
+		return json.Unmarshal(raw, v)
+	})
+}
+
+// Put stores v as JSON under key.
+func (s *Store) Put(bucket, key string, v any) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		if err != nil {
+			return err
+		}
+
+		return b.Put([]byte(key), raw)
+	})
+}
+
+func (s *Store) Delete(bucket, key string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return nil
+		}
+
+		return b.Delete([]byte(key))
+	})
+}
+
+// DeleteFunc deletes the values of bucket for which del returns true, del gets
+// the JSON value.
+func (s *Store) DeleteFunc(bucket string, del func(key string, raw []byte) bool) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return nil
+		}
+
+		keys := [][]byte{}
+		err := b.ForEach(func(k, v []byte) error {
+			if del(string(k), v) {
+				keys = append(keys, k)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, k := range keys {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,225 @@
+// Package corpus evaluates titleparser.Parse against a golden set of release names.
+//
+// The corpus is stored as JSON lines, one release per line:
+//
+//	{"name": "The.Matrix.1999.1080p.BluRay.x264-GROUP", "expected": {"title": "The Matrix", "year": 1999, ...}}
+//
+// Every field of the expected value is compared, so an omitted field means
+// the parser is expected to leave it empty.
+package corpus
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dbytex91/streamx/internal/titleparser"
+)
+
+const (
+	FieldTitle         = "title"
+	FieldYear          = "year"
+	FieldResolution    = "resolution"
+	FieldQuality       = "quality"
+	FieldCodec         = "codec"
+	FieldAudio         = "audio"
+	FieldAudioChannels = "audioChannels"
+	FieldAudioObject   = "audioObject"
+	FieldDualAudio     = "dualAudio"
+	FieldMultiAudio    = "multiAudio"
+	FieldThreeD        = "threeD"
+	FieldSeason        = "season"
+	FieldEpisode       = "episode"
+)
+
+// Fields lists every field that is scored, in report order.
+var Fields = []string{
+	FieldTitle,
+	FieldYear,
+	FieldResolution,
+	FieldQuality,
+	FieldCodec,
+	FieldAudio,
+	FieldAudioChannels,
+	FieldAudioObject,
+	FieldDualAudio,
+	FieldMultiAudio,
+	FieldThreeD,
+	FieldSeason,
+	FieldEpisode,
+}
+
+var titleSeparators = regexp.MustCompile(`[\s._]+`)
+
+type Expected struct {
+	Title         string `json:"title"`
+	Year          int    `json:"year,omitempty"`
+	Resolution    int    `json:"resolution,omitempty"`
+	Quality       string `json:"quality,omitempty"`
+	Codec         string `json:"codec,omitempty"`
+	Audio         string `json:"audio,omitempty"`
+	AudioChannels string `json:"audioChannels,omitempty"`
+	AudioObject   string `json:"audioObject,omitempty"`
+	DualAudio     bool   `json:"dualAudio,omitempty"`
+	MultiAudio    bool   `json:"multiAudio,omitempty"`
+	ThreeD        bool   `json:"threeD,omitempty"`
+	FromSeason    int    `json:"fromSeason,omitempty"`
+	ToSeason      int    `json:"toSeason,omitempty"`
+	Episode       int    `json:"episode,omitempty"`
+}
+
+type Entry struct {
+	Name     string   `json:"name"`
+	Expected Expected `json:"expected"`
+}
+
+type FieldResult struct {
+	Field   string
+	Correct int
+	Total   int
+}
+
+// Accuracy returns the share of correctly parsed entries in the range [0, 1].
+func (f FieldResult) Accuracy() float64 {
+	if f.Total == 0 {
+		return 0
+	}
+
+	return float64(f.Correct) / float64(f.Total)
+}
+
+type Mismatch struct {
+	Name     string
+	Field    string
+	Expected string
+	Actual   string
+}
+
+type Report struct {
+	Entries    int
+	Fields     []FieldResult
+	Mismatches []Mismatch
+}
+
+// Field returns the result for a single field.
+func (r *Report) Field(field string) FieldResult {
+	for _, f := range r.Fields {
+		if f.Field == field {
+			return f
+		}
+	}
+
+	return FieldResult{Field: field}
+}
+
+// Load reads a JSON lines corpus file.
+func Load(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries := []Entry{}
+	scanner := bufio.NewScanner(f)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		entry := Entry{}
+		if err := json.Unmarshal([]byte(text), &entry); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, line, err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// Evaluate parses every entry and compares the result with the expected values.
+func Evaluate(entries []Entry) *Report {
+	report := &Report{
+		Entries: len(entries),
+		Fields:  make([]FieldResult, len(Fields)),
+	}
+	for i, field := range Fields {
+		report.Fields[i].Field = field
+	}
+
+	for _, entry := range entries {
+		expected := fieldValues(entry.Expected)
+		actual := fieldValues(fromMetaInfo(titleparser.Parse(entry.Name)))
+		for i, field := range Fields {
+			report.Fields[i].Total++
+			if expected[field] == actual[field] {
+				report.Fields[i].Correct++
+				continue
+			}
+
+			report.Mismatches = append(report.Mismatches, Mismatch{
+				Name:     entry.Name,
+				Field:    field,
+				Expected: expected[field],
+				Actual:   actual[field],
+			})
+		}
+	}
+
+	return report
+}
+
+// NormalizeTitle turns a raw title prefix such as "The.Matrix." into "The Matrix".
+func NormalizeTitle(title string) string {
+	title = titleSeparators.ReplaceAllString(title, " ")
+	return strings.TrimRight(strings.TrimSpace(title), " -([")
+}
+
+func fromMetaInfo(mi *titleparser.MetaInfo) Expected {
+	return Expected{
+		Title:         mi.Title,
+		Year:          mi.Year,
+		Resolution:    mi.Resolution,
+		Quality:       mi.Quality,
+		Codec:         mi.Codec,
+		Audio:         mi.Audio,
+		AudioChannels: mi.AudioChannels,
+		AudioObject:   mi.AudioObject,
+		DualAudio:     mi.DualAudio,
+		MultiAudio:    mi.MultiAudio,
+		ThreeD:        mi.ThreeD,
+		FromSeason:    mi.FromSeason,
+		ToSeason:      mi.ToSeason,
+		Episode:       mi.Episode,
+	}
+}
+
+func fieldValues(e Expected) map[string]string {
+	return map[string]string{
+		FieldTitle:         NormalizeTitle(e.Title),
+		FieldYear:          strconv.Itoa(e.Year),
+		FieldResolution:    strconv.Itoa(e.Resolution),
+		FieldQuality:       e.Quality,
+		FieldCodec:         e.Codec,
+		FieldAudio:         e.Audio,
+		FieldAudioChannels: e.AudioChannels,
+		FieldAudioObject:   e.AudioObject,
+		FieldDualAudio:     strconv.FormatBool(e.DualAudio),
+		FieldMultiAudio:    strconv.FormatBool(e.MultiAudio),
+		FieldThreeD:        strconv.FormatBool(e.ThreeD),
+		FieldSeason:        fmt.Sprintf("%d-%d", e.FromSeason, e.ToSeason),
+		FieldEpisode:       strconv.Itoa(e.Episode),
+	}
+}
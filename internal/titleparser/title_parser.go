@@ -6,130 +6,304 @@ import (
 	"strings"
 )
 
+// parser looks for one piece of information in the title, stores it in the
+// MetaInfo and returns the index where the match starts, or -1 when nothing was found.
+type parser func(string, *MetaInfo) int
+
 var (
-	parsers = []func(string, *MetaInfo) int{
-		parseYear(`(?:\b((?:19[0-9]|20[0-9])[0-9])\b)|(?:\(((?:19[0-9]|20[0-9])[0-9])\))`),
-		parseResolution(`(?i)([0-9]{3,4})[pi]`),
-		matchAndSetResolution(`(?i)(4k)`, 2160),
-		matchAndSetQuality(`(?i)\b(?:HD-?)?CAM(?:rip)?\b`, "cam"),
-		matchAndSetQuality(`(?i)\b(?:HD-?)?T(?:ELE)?S(?:YNC)?\b`, "telesync"),
-		matchAndSetQuality(`(?i)\bTS-?Rip\b`, "telesync"),
-		parseQuality(`(?i)\bHD-?Rip\b`),
-		parseQuality(`(?i)\bBRRip\b`),
-		parseQuality(`(?i)\bBDRip\b`),
-		parseQuality(`(?i)\bDVDRip\b`),
-		matchAndSetQuality(`(?i)\bDVD(?:R[0-9])?\b`, "dvd"),
-		parseQuality(`(?i)\bDVDscr\b`),
-		parseQuality(`(?i)\b(?:HD-?)?TVRip\b`),
-		parseQuality(`\bTC\b`),
-		parseQuality(`(?i)\bPPVRip\b`),
-		parseQuality(`(?i)\bR5\b`),
-		parseQuality(`(?i)\bVHSSCR\b`),
-		matchAndSetQuality(`(?i)\bBlu-?ray(?:[\s\.]|.+\b)Remux\b`, "brremux"),
-		matchAndSetQuality(`(?i)\bBlu-?ray\b`, "bluray"),
-		parseQuality(`(?i)\bWEB-?DL\b`),
-		parseQuality(`(?i)\bWEB-?Rip\b`),
-		parseQuality(`(?i)\b(?:DL|WEB|BD|BR)REMUX\b`),
-		parseQuality(`(?i)\b(DivX|XviD)\b`),
-		parseQuality(`(?i)HDTV`),
-		parseCodec(`(?i)dvix|mpeg2|divx|xvid|[xh][-. ]?26[45]|avc|hevc`),
-		parseAudio(`MD|MP3|mp3|FLAC|Atmos|DTS(?:-HD)?|TrueHD`),
-		parseAudio(`(?i)Dual[- ]Audio`),
-		matchAndSetAudio(`(?i)AC-?3(?:\.5\.1)?`, "ac3"),
-		matchAndSetAudio(`(?i)DD5[. ]?1`, "dd5.1"),
-		matchAndSetAudio(`(?i)AAC(?:[. ]?2[. ]0)?`, "aac"),
-		parseContainer(`(?i)\b(MKV|AVI|MP4)\b`),
-		parse3D(`(?i)\b((3D))\b`),
-		parseSeasonAndEpisode(`(?i)S(\d{2})-?E(\d{2})`),
-		parseMultiSeason(`(?i)S(\d{2})\s*(?:to|-)?\s*S(\d{2})`),
-		parseMultiSeason(`(?i)\bseason\s+(\d{1,2})[\s-]+(\d{1,2})\b`),
-		parseSingleSeason(`(?i)\bs(\d{2})\b`),
-		parseSingleSeason(`(?i)\bseason[- ]?(\d{1,2})\b`),
-		parseLanguage(`\bFR(?:ENCH)?\b`),
-		parseFillerWords(`(?i)[-\s\.\(]+\b(?:TV|Complete|Full) series\b`),
+	parsers = []parser{
+		parseYear(`(?:\b((?:19[0-9]|20[0-9])[0-9])\b)|(?:\(((?:19[0-9]|20[0-9])[0-9])\))`, 0.8),
+		parseResolution(`(?i)([0-9]{3,4})[pi]`, 0.95),
+		matchAndSetResolution(`(?i)(4k)`, 2160, 0.85),
+		matchAndSetQuality(`(?i)\b(?:HD-?)?CAM(?:rip)?\b`, "cam", 0.9),
+		matchAndSetQuality(`(?i)\b(?:HD-?)?T(?:ELE)?S(?:YNC)?\b`, "telesync", 0.6),
+		matchAndSetQuality(`(?i)\bTS-?Rip\b`, "telesync", 0.9),
+		parseQuality(`(?i)\bHD-?Rip\b`, 0.9),
+		parseQuality(`(?i)\bBRRip\b`, 0.9),
+		parseQuality(`(?i)\bBDRip\b`, 0.9),
+		parseQuality(`(?i)\bDVDRip\b`, 0.9),
+		matchAndSetQuality(`(?i)\bDVD(?:R[0-9])?\b`, "dvd", 0.9),
+		parseQuality(`(?i)\bDVDscr\b`, 0.9),
+		parseQuality(`(?i)\b(?:HD-?)?TVRip\b`, 0.9),
+		parseQuality(`\bTC\b`, 0.5),
+		parseQuality(`(?i)\bPPVRip\b`, 0.9),
+		parseQuality(`(?i)\bR5\b`, 0.6),
+		parseQuality(`(?i)\bVHSSCR\b`, 0.9),
+		matchAndSetQuality(`(?i)\bBlu-?ray(?:[\s\.]|.+\b)Remux\b`, "brremux", 0.9),
+		matchAndSetQuality(`(?i)\bBlu-?ray\b`, "bluray", 0.9),
+		parseQuality(`(?i)\bWEB-?DL\b`, 0.9),
+		parseQuality(`(?i)\bWEB-?Rip\b`, 0.9),
+		parseQuality(`(?i)\b(?:DL|WEB|BD|BR)REMUX\b`, 0.9),
+		parseQuality(`(?i)\b(DivX|XviD)\b`, 0.4),
+		parseQuality(`(?i)HDTV`, 0.8),
+		parseCodec(`(?i)dvix|mpeg2|divx|xvid|[xh][-. ]?26[45]|avc|hevc`, 0.9),
+		matchAndSetAudio(`(?i)\bTrueHD\b`, "truehd", 0.9),
+		matchAndSetAudio(`(?i)\bDTS[-. ]?HD(?:[-. ]?(?:MA|HRA))?\b`, "dts-hd", 0.9),
+		matchAndSetAudio(`(?i)\bDTS\b`, "dts", 0.9),
+		matchAndSetAudio(`(?i)\b(?:E-?AC-?3|DDP|DD\+)`, "eac3", 0.9),
+		matchAndSetAudio(`(?i)\b(?:AC-?3|DD)`+channelSuffix+`\b`, "ac3", 0.9),
+		matchAndSetAudio(`(?i)\bAAC`+channelSuffix+`\b`, "aac", 0.9),
+		matchAndSetAudio(`(?i)\bFLAC`+channelSuffix+`\b`, "flac", 0.9),
+		matchAndSetAudio(`(?i)\bOPUS\b`, "opus", 0.9),
+		matchAndSetAudio(`(?i)\bMP3\b`, "mp3", 0.9),
+		matchAndSetAudio(`\bMD\b`, "md", 0.5),
+		parseAudioObject(`(?i)\bAtmos\b`, "atmos", 0.9),
+		parseAudioObject(`(?i)\bDTS[-:. ]?X\b`, "dts:x", 0.9),
+		parseAudioChannels(`(?i)(?:^|[^0-9])([1-9])[. ]([01])(?:ch)?\b`, 0.7),
+		parseAudioChannelCount(`(?i)\b([2-8])[. ]?ch\b`, 0.8),
+		parseAudioChannelCount(`(?i)\b(Stereo)\b`, 0.6),
+		parseDualAudio(`(?i)\bDual[- .]?Audio\b`, false, 0.8),
+		parseMultiAudio(`(?i)\bMulti[- .]?Audio\b`, false, 0.8),
+		// bare tokens are title words too, e.g. "Dual.2022", they only count
+		// past the year or the resolution
+		parseDualAudio(`\bDUAL\b`, true, 0.8),
+		parseMultiAudio(`\bMULTi\b|\bMULTI\b`, true, 0.8),
+		parseContainer(`(?i)\b(MKV|AVI|MP4)\b`, 0.9),
+		parse3D(`(?i)\b((3D))\b`, 0.7),
+		parseSeasonAndEpisode(`(?i)S(\d{2})-?E(\d{2})`, 0.95),
+		parseMultiSeason(`(?i)S(\d{2})\s*(?:to|-)?\s*S(\d{2})`, 0.9),
+		parseMultiSeason(`(?i)\bseason\s+(\d{1,2})[\s-]+(\d{1,2})\b`, 0.9),
+		parseSingleSeason(`(?i)\bs(\d{2})\b`, 0.8),
+		parseSingleSeason(`(?i)\bseason[- ]?(\d{1,2})\b`, 0.85),
+		parseAbsoluteEpisode(`\s-\s(\d{1,4})(?:v\d)?(?:\s*[\[(]|\s*$|\.\w{3,4}$)`, 0.75),
+		parseCRC(`\[([0-9A-Fa-f]{8})\]`, 0.9),
+		parseLanguage(`\bFR(?:ENCH)?\b`, 0.7),
+		parseFillerWords(`(?i)[-\s\.\(]+\b(?:TV|Complete|Full) series\b`, 0.9),
 	}
 )
 
+// releaseGroupPrefix matches the fansub group leading a release name, e.g.
+// "[SubsPlease] Frieren - 28 (1080p)".
+var releaseGroupPrefix = regexp.MustCompile(`^\s*\[([^\]]+)\]\s*`)
+
+// channelSuffix matches an optional channel layout glued to an audio codec,
+// e.g. the "5.1" in "DD5.1" or "AAC2.0".
+const channelSuffix = `(?:[. ]?[1-7][. ]?[01])?`
+
+var channelsByCount = map[string]string{
+	"2":      "2.0",
+	"3":      "2.1",
+	"4":      "4.0",
+	"5":      "5.0",
+	"6":      "5.1",
+	"7":      "6.1",
+	"8":      "7.1",
+	"stereo": "2.0",
+}
+
+// Field names the MetaInfo values a parser can fill in.
+type Field string
+
+const (
+	FieldTitle         Field = "title"
+	FieldYear          Field = "year"
+	FieldResolution    Field = "resolution"
+	FieldQuality       Field = "quality"
+	FieldCodec         Field = "codec"
+	FieldAudio         Field = "audio"
+	FieldAudioChannels Field = "audioChannels"
+	FieldAudioObject   Field = "audioObject"
+	FieldDualAudio     Field = "dualAudio"
+	FieldMultiAudio    Field = "multiAudio"
+	FieldContainer     Field = "container"
+	FieldThreeD        Field = "threeD"
+	FieldSeason        Field = "season"
+	FieldEpisode       Field = "episode"
+	FieldFiller        Field = "filler"
+	FieldLanguage      Field = "language"
+	FieldGroup         Field = "group"
+	FieldCRC           Field = "crc"
+)
+
+const (
+	// noAnchorConfidence is used when nothing but the title was recognised,
+	// so the whole string had to be taken as the title.
+	noAnchorConfidence = 0.2
+	// weakAnchorConfidence is used when the title ends at a match that is not
+	// tracked as a field.
+	weakAnchorConfidence = 0.5
+	// weakAnchorPenalty lowers the title confidence when the title ends at a
+	// token that usually sits deep inside a release name (codec, audio...)
+	// rather than right after the title (year, resolution, season).
+	weakAnchorPenalty = 0.7
+)
+
+// strongAnchors are fields that typically follow the title directly.
+var strongAnchors = map[Field]bool{
+	FieldYear:       true,
+	FieldResolution: true,
+	FieldSeason:     true,
+	FieldEpisode:    true,
+	FieldFiller:     true,
+}
+
+// Match tells where a field was found in the parsed title and how certain the
+// parser is about the value, from 0 (guess) to 1 (certain).
+type Match struct {
+	Start      int
+	End        int
+	Text       string
+	Confidence float64
+}
+
 type MetaInfo struct {
-	Resolution int
-	Year       int
-	Quality    string
-	Codec      string
-	Audio      string
-	Container  string
-	ThreeD     bool
-	FromSeason int
-	ToSeason   int
-	Episode    int
-	Title      string
-	Language   string
+	Resolution    int
+	Year          int
+	Quality       string
+	Codec         string
+	Audio         string
+	AudioChannels string
+	AudioObject   string
+	DualAudio     bool
+	MultiAudio    bool
+	Container     string
+	ThreeD        bool
+	FromSeason    int
+	ToSeason      int
+	Episode       int
+	Title         string
+	Language      string
+	Group         string
+	CRC           string
+	Matches       map[Field]Match
 }
 
 func Parse(title string) *MetaInfo {
-	m := &MetaInfo{}
+	m := &MetaInfo{Matches: map[Field]Match{}}
+	start := parseReleaseGroup(title, m)
 	index := len(title)
 
 	for _, parser := range parsers {
 		nextIndex := parser(title, m)
-		if nextIndex >= 0 && nextIndex < index {
+		if nextIndex >= start && nextIndex < index {
 			index = nextIndex
 		}
 	}
 
-	m.Title = title[0:index]
+	m.Title = title[start:index]
+	m.Matches[FieldTitle] = Match{
+		Start:      start,
+		End:        index,
+		Text:       m.Title,
+		Confidence: m.titleConfidence(index, len(title)),
+	}
 
 	return m
 }
 
-func findValue(value *string, title string, regex *regexp.Regexp) int {
+// Confidence returns how certain the parser is about the given field, 0 when
+// the field wasn't found at all.
+func (m *MetaInfo) Confidence(field Field) float64 {
+	return m.Matches[field].Confidence
+}
+
+// titleConfidence rates the title by the match that ended it: a title followed
+// by a year or SxxEyy is far more reliable than one cut at a codec, or one
+// that is the whole release name because nothing else was recognised.
+func (m *MetaInfo) titleConfidence(end int, length int) float64 {
+	if strings.Trim(m.Title, " .-_([") == "" {
+		return 0
+	}
+
+	if end == length {
+		return noAnchorConfidence
+	}
+
+	anchor, found := Field(""), false
+	for field, match := range m.Matches {
+		if match.Start == end && (!found || strongAnchors[field]) {
+			anchor, found = field, true
+		}
+	}
+
+	if !found {
+		return weakAnchorConfidence
+	}
+
+	if strongAnchors[anchor] {
+		return m.Matches[anchor].Confidence
+	}
+
+	return m.Matches[anchor].Confidence * weakAnchorPenalty
+}
+
+// afterYearOrResolution tells whether start lies past the year or the
+// resolution, where the title has ended.
+func (m *MetaInfo) afterYearOrResolution(start int) bool {
+	for _, field := range []Field{FieldYear, FieldResolution} {
+		if match, ok := m.Matches[field]; ok && match.End <= start {
+			return true
+		}
+	}
+
+	return false
+}
+
+// record stores the match for field and returns its start, or -1 if loc is empty.
+func (m *MetaInfo) record(field Field, title string, loc []int, confidence float64) int {
+	if loc == nil {
+		return -1
+	}
+
+	m.Matches[field] = Match{
+		Start:      loc[0],
+		End:        loc[1],
+		Text:       title[loc[0]:loc[1]],
+		Confidence: confidence,
+	}
+
+	return loc[0]
+}
+
+func findValue(value *string, title string, regex *regexp.Regexp) []int {
 	if *value != "" {
 		// don't overwrite the existing value
-		return -1
+		return nil
 	}
 
 	matches := regex.FindAllStringIndex(title, -1)
 	if len(matches) > 0 {
 		loc := matches[len(matches)-1]
 		*value = strings.ToLower(title[loc[len(loc)-2]:loc[len(loc)-1]])
-		return loc[0]
+		return loc
 	}
 
-	return -1
+	return nil
 }
 
-func findSubValue(value *string, title string, regex *regexp.Regexp) int {
+func findSubValue(value *string, title string, regex *regexp.Regexp) []int {
 	if *value != "" {
 		// don't overwrite the existing value
-		return -1
+		return nil
 	}
 
 	matches := regex.FindAllStringSubmatchIndex(title, -1)
 	if len(matches) > 0 && len(matches[len(matches)-1]) > 3 {
 		loc := matches[len(matches)-1]
 		*value = strings.ToLower(title[loc[2]:loc[3]])
-		return loc[0]
+		return loc
 	}
 
-	return -1
+	return nil
 }
 
-func findAndSet(value *string, title string, regex *regexp.Regexp, target string) int {
+func findAndSet(value *string, title string, regex *regexp.Regexp, target string) []int {
 	if *value != "" {
 		// don't overwrite the existing value
-		return -1
+		return nil
 	}
 
 	matches := regex.FindAllStringIndex(title, -1)
 	if len(matches) > 0 {
 		loc := matches[len(matches)-1]
 		*value = target
-		return loc[0]
+		return loc
 	}
 
-	return -1
+	return nil
 }
 
-func parseYear(pattern string) func(string, *MetaInfo) int {
+func parseYear(pattern string, confidence float64) parser {
 	compiled := regexp.MustCompile(pattern)
 	return func(title string, mi *MetaInfo) int {
 		if mi.Year > 0 {
@@ -137,16 +311,26 @@ func parseYear(pattern string) func(string, *MetaInfo) int {
 		}
 
 		var year string
-		index := findValue(&year, title, compiled)
-		if index != -1 {
-			mi.Year, _ = strconv.Atoi(year)
+		loc := findValue(&year, title, compiled)
+		if loc != nil {
+			// parenthesised years keep their brackets in the value
+			parsed, err := strconv.Atoi(strings.Trim(year, "()"))
+			if err != nil {
+				return -1
+			}
+			mi.Year = parsed
 		}
 
-		return index
+		if loc != nil && loc[0] == 0 {
+			// a year at the very start is more likely part of the title, e.g. "1917"
+			return mi.record(FieldYear, title, loc, confidence/2)
+		}
+
+		return mi.record(FieldYear, title, loc, confidence)
 	}
 }
 
-func parseResolution(pattern string) func(string, *MetaInfo) int {
+func parseResolution(pattern string, confidence float64) parser {
 	compiled := regexp.MustCompile(pattern)
 	return func(title string, mi *MetaInfo) int {
 		if mi.Resolution > 0 {
@@ -154,16 +338,16 @@ func parseResolution(pattern string) func(string, *MetaInfo) int {
 		}
 
 		var resolution string
-		index := findSubValue(&resolution, title, compiled)
-		if index != -1 {
+		loc := findSubValue(&resolution, title, compiled)
+		if loc != nil {
 			mi.Resolution, _ = strconv.Atoi(resolution)
 		}
 
-		return index
+		return mi.record(FieldResolution, title, loc, confidence)
 	}
 }
 
-func matchAndSetResolution(pattern string, value int) func(string, *MetaInfo) int {
+func matchAndSetResolution(pattern string, value int, confidence float64) parser {
 	compiled := regexp.MustCompile(pattern)
 	return func(title string, mi *MetaInfo) int {
 		if mi.Resolution > 0 {
@@ -171,64 +355,134 @@ func matchAndSetResolution(pattern string, value int) func(string, *MetaInfo) in
 		}
 
 		var resolution string
-		index := findValue(&resolution, title, compiled)
-		if index != -1 {
+		loc := findValue(&resolution, title, compiled)
+		if loc != nil {
 			mi.Resolution = value
 		}
 
-		return index
+		return mi.record(FieldResolution, title, loc, confidence)
 	}
 }
 
-func parseQuality(pattern string) func(string, *MetaInfo) int {
+func parseQuality(pattern string, confidence float64) parser {
 	compiled := regexp.MustCompile(pattern)
 	return func(title string, mi *MetaInfo) int {
-		return findValue(&mi.Quality, title, compiled)
+		return mi.record(FieldQuality, title, findValue(&mi.Quality, title, compiled), confidence)
 	}
 }
 
-func matchAndSetQuality(pattern string, value string) func(string, *MetaInfo) int {
+func matchAndSetQuality(pattern string, value string, confidence float64) parser {
 	compiled := regexp.MustCompile(pattern)
 	return func(title string, mi *MetaInfo) int {
-		return findAndSet(&mi.Quality, title, compiled, value)
+		return mi.record(FieldQuality, title, findAndSet(&mi.Quality, title, compiled, value), confidence)
 	}
 }
 
-func parseCodec(pattern string) func(string, *MetaInfo) int {
+func parseCodec(pattern string, confidence float64) parser {
 	compiled := regexp.MustCompile(pattern)
 	return func(title string, mi *MetaInfo) int {
-		index := findValue(&mi.Codec, title, compiled)
-		if index != -1 {
+		loc := findValue(&mi.Codec, title, compiled)
+		if loc != nil {
 			mi.Codec = strings.ReplaceAll(mi.Codec, ".", "")
 			mi.Codec = strings.ReplaceAll(mi.Codec, "-", "")
 			mi.Codec = strings.ReplaceAll(mi.Codec, " ", "")
 		}
-		return index
+		return mi.record(FieldCodec, title, loc, confidence)
 	}
 }
 
-func parseAudio(pattern string) func(string, *MetaInfo) int {
+func matchAndSetAudio(pattern string, value string, confidence float64) parser {
 	compiled := regexp.MustCompile(pattern)
 	return func(title string, mi *MetaInfo) int {
-		return findValue(&mi.Audio, title, compiled)
+		return mi.record(FieldAudio, title, findAndSet(&mi.Audio, title, compiled, value), confidence)
 	}
 }
 
-func matchAndSetAudio(pattern string, value string) func(string, *MetaInfo) int {
+func parseAudioObject(pattern string, value string, confidence float64) parser {
 	compiled := regexp.MustCompile(pattern)
 	return func(title string, mi *MetaInfo) int {
-		return findAndSet(&mi.Audio, title, compiled, value)
+		return mi.record(FieldAudioObject, title, findAndSet(&mi.AudioObject, title, compiled, value), confidence)
 	}
 }
 
-func parseContainer(pattern string) func(string, *MetaInfo) int {
+func parseAudioChannels(pattern string, confidence float64) parser {
 	compiled := regexp.MustCompile(pattern)
 	return func(title string, mi *MetaInfo) int {
-		return findValue(&mi.Container, title, compiled)
+		if mi.AudioChannels != "" {
+			return -1
+		}
+
+		matches := compiled.FindAllStringSubmatchIndex(title, -1)
+		if len(matches) > 0 && len(matches[len(matches)-1]) > 5 {
+			loc := matches[len(matches)-1]
+			mi.AudioChannels = title[loc[2]:loc[3]] + "." + title[loc[4]:loc[5]]
+			// the match starts at the separator before the channels
+			return mi.record(FieldAudioChannels, title, []int{loc[2], loc[5]}, confidence)
+		}
+
+		return -1
 	}
 }
 
-func parse3D(pattern string) func(string, *MetaInfo) int {
+func parseAudioChannelCount(pattern string, confidence float64) parser {
+	compiled := regexp.MustCompile(pattern)
+	return func(title string, mi *MetaInfo) int {
+		if mi.AudioChannels != "" {
+			return -1
+		}
+
+		var count string
+		loc := findSubValue(&count, title, compiled)
+		if loc != nil {
+			mi.AudioChannels = channelsByCount[count]
+		}
+
+		return mi.record(FieldAudioChannels, title, loc, confidence)
+	}
+}
+
+func parseDualAudio(pattern string, afterAnchor bool, confidence float64) parser {
+	compiled := regexp.MustCompile(pattern)
+	return func(title string, mi *MetaInfo) int {
+		if mi.DualAudio {
+			return -1
+		}
+
+		var dual string
+		loc := findValue(&dual, title, compiled)
+		if loc != nil && afterAnchor && !mi.afterYearOrResolution(loc[0]) {
+			loc = nil
+		}
+		mi.DualAudio = loc != nil
+		return mi.record(FieldDualAudio, title, loc, confidence)
+	}
+}
+
+func parseMultiAudio(pattern string, afterAnchor bool, confidence float64) parser {
+	compiled := regexp.MustCompile(pattern)
+	return func(title string, mi *MetaInfo) int {
+		if mi.MultiAudio {
+			return -1
+		}
+
+		var multi string
+		loc := findValue(&multi, title, compiled)
+		if loc != nil && afterAnchor && !mi.afterYearOrResolution(loc[0]) {
+			loc = nil
+		}
+		mi.MultiAudio = loc != nil
+		return mi.record(FieldMultiAudio, title, loc, confidence)
+	}
+}
+
+func parseContainer(pattern string, confidence float64) parser {
+	compiled := regexp.MustCompile(pattern)
+	return func(title string, mi *MetaInfo) int {
+		return mi.record(FieldContainer, title, findValue(&mi.Container, title, compiled), confidence)
+	}
+}
+
+func parse3D(pattern string, confidence float64) parser {
 	compiled := regexp.MustCompile(pattern)
 	return func(title string, mi *MetaInfo) int {
 		if mi.ThreeD {
@@ -236,13 +490,13 @@ func parse3D(pattern string) func(string, *MetaInfo) int {
 		}
 
 		var threeD string
-		index := findValue(&threeD, title, compiled)
-		mi.ThreeD = index != -1
-		return index
+		loc := findValue(&threeD, title, compiled)
+		mi.ThreeD = loc != nil
+		return mi.record(FieldThreeD, title, loc, confidence)
 	}
 }
 
-func parseSeasonAndEpisode(pattern string) func(string, *MetaInfo) int {
+func parseSeasonAndEpisode(pattern string, confidence float64) parser {
 	compiled := regexp.MustCompile(pattern)
 	return func(title string, mi *MetaInfo) int {
 		if mi.FromSeason > 0 {
@@ -255,14 +509,15 @@ func parseSeasonAndEpisode(pattern string) func(string, *MetaInfo) int {
 			mi.FromSeason, _ = strconv.Atoi(title[loc[2]:loc[3]])
 			mi.ToSeason = mi.FromSeason
 			mi.Episode, _ = strconv.Atoi(title[loc[4]:loc[5]])
-			return loc[0]
+			mi.record(FieldEpisode, title, loc, confidence)
+			return mi.record(FieldSeason, title, loc, confidence)
 		}
 
 		return -1
 	}
 }
 
-func parseMultiSeason(pattern string) func(string, *MetaInfo) int {
+func parseMultiSeason(pattern string, confidence float64) parser {
 	compiled := regexp.MustCompile(pattern)
 	return func(title string, mi *MetaInfo) int {
 		if mi.FromSeason > 0 {
@@ -274,14 +529,14 @@ func parseMultiSeason(pattern string) func(string, *MetaInfo) int {
 			loc := matches[len(matches)-1]
 			mi.FromSeason, _ = strconv.Atoi(title[loc[2]:loc[3]])
 			mi.ToSeason, _ = strconv.Atoi(title[loc[4]:loc[5]])
-			return loc[0]
+			return mi.record(FieldSeason, title, loc, confidence)
 		}
 
 		return -1
 	}
 }
 
-func parseSingleSeason(pattern string) func(string, *MetaInfo) int {
+func parseSingleSeason(pattern string, confidence float64) parser {
 	compiled := regexp.MustCompile(pattern)
 	return func(title string, mi *MetaInfo) int {
 		if mi.FromSeason > 0 {
@@ -293,24 +548,68 @@ func parseSingleSeason(pattern string) func(string, *MetaInfo) int {
 			loc := matches[len(matches)-1]
 			mi.FromSeason, _ = strconv.Atoi(title[loc[2]:loc[3]])
 			mi.ToSeason = mi.FromSeason
-			return loc[0]
+			return mi.record(FieldSeason, title, loc, confidence)
 		}
 
 		return -1
 	}
 }
 
-func parseLanguage(pattern string) func(string, *MetaInfo) int {
+// parseReleaseGroup reads a leading "[Group]" and returns where the title starts.
+func parseReleaseGroup(title string, mi *MetaInfo) int {
+	loc := releaseGroupPrefix.FindStringSubmatchIndex(title)
+	if loc == nil {
+		return 0
+	}
+
+	mi.Group = title[loc[2]:loc[3]]
+	mi.record(FieldGroup, title, loc, 0.9)
+	return loc[1]
+}
+
+// parseAbsoluteEpisode reads the fansub style episode number in "Title - 28 [1080p]".
+func parseAbsoluteEpisode(pattern string, confidence float64) parser {
+	compiled := regexp.MustCompile(pattern)
+	return func(title string, mi *MetaInfo) int {
+		if mi.Episode > 0 || mi.FromSeason > 0 {
+			return -1
+		}
+
+		loc := compiled.FindStringSubmatchIndex(title)
+		if loc == nil {
+			return -1
+		}
+
+		if year, ok := mi.Matches[FieldYear]; ok && year.Start < loc[3] && year.End > loc[2] {
+			// "Title - 2019 (1080p)" is a year, not the 2019th episode
+			return -1
+		}
+
+		mi.Episode, _ = strconv.Atoi(title[loc[2]:loc[3]])
+		return mi.record(FieldEpisode, title, loc, confidence)
+	}
+}
+
+func parseCRC(pattern string, confidence float64) parser {
+	compiled := regexp.MustCompile(pattern)
+	return func(title string, mi *MetaInfo) int {
+		loc := findSubValue(&mi.CRC, title, compiled)
+		mi.CRC = strings.ToUpper(mi.CRC)
+		return mi.record(FieldCRC, title, loc, confidence)
+	}
+}
+
+func parseLanguage(pattern string, confidence float64) parser {
 	compiled := regexp.MustCompile(pattern)
 	return func(title string, mi *MetaInfo) int {
-		return findValue(&mi.Quality, title, compiled)
+		return mi.record(FieldLanguage, title, findValue(&mi.Language, title, compiled), confidence)
 	}
 }
 
-func parseFillerWords(pattern string) func(string, *MetaInfo) int {
+func parseFillerWords(pattern string, confidence float64) parser {
 	compiled := regexp.MustCompile(pattern)
 	return func(title string, mi *MetaInfo) int {
 		var filler string
-		return findValue(&filler, title, compiled)
+		return mi.record(FieldFiller, title, findValue(&filler, title, compiled), confidence)
 	}
 }
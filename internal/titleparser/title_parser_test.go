@@ -0,0 +1,81 @@
+package titleparser_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dbytex91/streamx/internal/titleparser"
+	"github.com/dbytex91/streamx/internal/titleparser/corpus"
+)
+
+// minAccuracy is the accuracy each field must keep on the golden corpus.
+// Raise these numbers when a parser change improves a field; never lower them
+// without explaining the regression.
+var minAccuracy = map[string]float64{
+	corpus.FieldTitle:         0.985,
+	corpus.FieldYear:          0.995,
+	corpus.FieldResolution:    1,
+	corpus.FieldQuality:       0.86,
+	corpus.FieldCodec:         1,
+	corpus.FieldAudio:         1,
+	corpus.FieldAudioChannels: 1,
+	corpus.FieldAudioObject:   1,
+	corpus.FieldDualAudio:     1,
+	corpus.FieldMultiAudio:    1,
+	corpus.FieldThreeD:        1,
+	corpus.FieldSeason:        0.99,
+	corpus.FieldEpisode:       1,
+}
+
+func TestParseCorpus(t *testing.T) {
+	entries, err := corpus.Load("testdata/corpus.jsonl")
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+
+	report := corpus.Evaluate(entries)
+	for _, m := range report.Mismatches {
+		t.Logf("%s: expected %q, got %q in %s", m.Field, m.Expected, m.Actual, m.Name)
+	}
+
+	for _, field := range corpus.Fields {
+		result := report.Field(field)
+		assert.GreaterOrEqualf(t, result.Accuracy(), minAccuracy[field],
+			"%s accuracy dropped to %d/%d", field, result.Correct, result.Total)
+	}
+}
+
+func TestParseConfidence(t *testing.T) {
+	mi := titleparser.Parse("The.Matrix.1999.1080p.BluRay.x264-GROUP")
+
+	year := mi.Matches[titleparser.FieldYear]
+	assert.Equal(t, "1999", year.Text)
+	assert.Equal(t, 11, year.Start)
+	assert.Equal(t, 15, year.End)
+	assert.Equal(t, "The.Matrix.", mi.Matches[titleparser.FieldTitle].Text)
+	assert.GreaterOrEqual(t, mi.Confidence(titleparser.FieldTitle), 0.8)
+
+	bracketed := titleparser.Parse("Interstellar (2014) 1080p BluRay x264")
+	assert.Equal(t, 2014, bracketed.Year)
+	assert.Equal(t, "(2014)", bracketed.Matches[titleparser.FieldYear].Text)
+	assert.Equal(t, 0.8, bracketed.Confidence(titleparser.FieldYear))
+
+	channels := titleparser.Parse("Heat.1995.1080p.BluRay.DTS.5.1.x264").Matches[titleparser.FieldAudioChannels]
+	assert.Equal(t, "5.1", channels.Text)
+	assert.Equal(t, 27, channels.Start)
+
+	unknown := titleparser.Parse("Some Random Upload")
+	assert.Equal(t, "Some Random Upload", unknown.Title)
+	assert.Less(t, unknown.Confidence(titleparser.FieldTitle), 0.5)
+	assert.Zero(t, unknown.Confidence(titleparser.FieldYear))
+}
+
+func TestParseLanguage(t *testing.T) {
+	mi := titleparser.Parse("Amelie.2001.FRENCH.1080p.BluRay.x264.DTS-FGT")
+
+	assert.Equal(t, "french", mi.Language)
+	assert.Equal(t, "bluray", mi.Quality)
+	assert.Equal(t, "FRENCH", mi.Matches[titleparser.FieldLanguage].Text)
+	assert.Equal(t, "BluRay", mi.Matches[titleparser.FieldQuality].Text)
+}
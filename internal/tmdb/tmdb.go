@@ -0,0 +1,262 @@
+package tmdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dbytex91/streamx/internal/metadata"
+	"github.com/dbytex91/streamx/internal/model"
+	"github.com/dbytex91/streamx/internal/tracing"
+	"github.com/go-resty/resty/v2"
+)
+
+const (
+	defaultBaseURL = "https://api.themoviedb.org/3"
+	// maxAppendedSeasons is the number of append_to_response entries TMDB
+	// accepts in one request.
+	maxAppendedSeasons = 20
+)
+
+type TMDB struct {
+	client *resty.Client
+}
+
+type findResponse struct {
+	MovieResults []struct {
+		ID int `json:"id"`
+	} `json:"movie_results"`
+	TVResults []struct {
+		ID int `json:"id"`
+	} `json:"tv_results"`
+}
+
+type movieResponse struct {
+	Title             string `json:"title"`
+	OriginalTitle     string `json:"original_title"`
+	ReleaseDate       string `json:"release_date"`
+	Runtime           int    `json:"runtime"`
+	IMDBID            string `json:"imdb_id"`
+	AlternativeTitles struct {
+		Titles []alternativeTitle `json:"titles"`
+	} `json:"alternative_titles"`
+}
+
+type seriesResponse struct {
+	Name              string `json:"name"`
+	OriginalName      string `json:"original_name"`
+	FirstAirDate      string `json:"first_air_date"`
+	LastAirDate       string `json:"last_air_date"`
+	EpisodeRunTime    []int  `json:"episode_run_time"`
+	AlternativeTitles struct {
+		Results []alternativeTitle `json:"results"`
+	} `json:"alternative_titles"`
+	Seasons []struct {
+		SeasonNumber int `json:"season_number"`
+	} `json:"seasons"`
+}
+
+type seasonResponse struct {
+	Episodes []struct {
+		SeasonNumber  int    `json:"season_number"`
+		EpisodeNumber int    `json:"episode_number"`
+		Name          string `json:"name"`
+		Runtime       int    `json:"runtime"`
+	} `json:"episodes"`
+}
+
+type alternativeTitle struct {
+	Title string `json:"title"`
+}
+
+func New(apiKey string) *TMDB {
+	return NewWithBaseURL(defaultBaseURL, apiKey)
+}
+
+func NewWithBaseURL(baseURL string, apiKey string) *TMDB {
+	return &TMDB{
+		client: tracing.InstrumentResty(resty.New().
+			SetBaseURL(baseURL).
+			SetHeader("Accept", "application/json").
+			SetQueryParam("api_key", apiKey), "tmdb"),
+	}
+}
+
+func (t *TMDB) GetMovieById(ctx context.Context, id string) (*model.MetaInfo, error) {
+	found, err := t.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(found.MovieResults) == 0 {
+		return nil, metadata.ErrNotFound
+	}
+
+	movie := &movieResponse{}
+	err = t.get(ctx, fmt.Sprintf("/movie/%d", found.MovieResults[0].ID), map[string]string{
+		"append_to_response": "alternative_titles",
+	}, movie)
+	if err != nil {
+		return nil, err
+	}
+
+	year := parseYear(movie.ReleaseDate)
+	return &model.MetaInfo{
+		Name:             movie.Title,
+		OriginalName:     movie.OriginalTitle,
+		AlternativeNames: titles(movie.AlternativeTitles.Titles),
+		IMDBID:           parseIMDBID(id),
+		FromYear:         year,
+		ToYear:           year,
+		Runtime:          movie.Runtime,
+	}, nil
+}
+
+func (t *TMDB) GetSeriesById(ctx context.Context, id string) (*model.MetaInfo, error) {
+	found, err := t.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(found.TVResults) == 0 {
+		return nil, metadata.ErrNotFound
+	}
+
+	tvID := found.TVResults[0].ID
+	series := &seriesResponse{}
+	err = t.get(ctx, fmt.Sprintf("/tv/%d", tvID), map[string]string{
+		"append_to_response": "alternative_titles",
+	}, series)
+	if err != nil {
+		return nil, err
+	}
+
+	runtime := 0
+	if len(series.EpisodeRunTime) > 0 {
+		runtime = series.EpisodeRunTime[0]
+	}
+
+	seasons := make([]int, 0, len(series.Seasons))
+	for _, season := range series.Seasons {
+		seasons = append(seasons, season.SeasonNumber)
+	}
+
+	episodes, err := t.getEpisodes(ctx, tvID, seasons, runtime)
+	if err != nil {
+		return nil, err
+	}
+
+	toYear := parseYear(series.LastAirDate)
+	fromYear := parseYear(series.FirstAirDate)
+	if toYear == 0 {
+		toYear = fromYear
+	}
+
+	return &model.MetaInfo{
+		Name:             series.Name,
+		OriginalName:     series.OriginalName,
+		AlternativeNames: titles(series.AlternativeTitles.Results),
+		IMDBID:           parseIMDBID(id),
+		FromYear:         fromYear,
+		ToYear:           toYear,
+		Runtime:          runtime,
+		Episodes:         episodes,
+	}, nil
+}
+
+// getEpisodes loads the episode lists of the given seasons, appending as many
+// seasons as TMDB allows to each request.
+func (t *TMDB) getEpisodes(ctx context.Context, tvID int, seasons []int, defaultRuntime int) ([]model.Episode, error) {
+	episodes := []model.Episode{}
+	for start := 0; start < len(seasons); start += maxAppendedSeasons {
+		end := min(start+maxAppendedSeasons, len(seasons))
+		appended := make([]string, 0, end-start)
+		for _, season := range seasons[start:end] {
+			appended = append(appended, "season/"+strconv.Itoa(season))
+		}
+
+		result := map[string]json.RawMessage{}
+		err := t.get(ctx, fmt.Sprintf("/tv/%d", tvID), map[string]string{
+			"append_to_response": strings.Join(appended, ","),
+		}, &result)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range appended {
+			season := &seasonResponse{}
+			if err := json.Unmarshal(result[key], season); err != nil {
+				continue
+			}
+
+			for _, episode := range season.Episodes {
+				runtime := episode.Runtime
+				if runtime == 0 {
+					runtime = defaultRuntime
+				}
+
+				episodes = append(episodes, model.Episode{
+					Season:  episode.SeasonNumber,
+					Episode: episode.EpisodeNumber,
+					Title:   episode.Name,
+					Runtime: runtime,
+				})
+			}
+		}
+	}
+
+	return episodes, nil
+}
+
+func (t *TMDB) find(ctx context.Context, id string) (*findResponse, error) {
+	result := &findResponse{}
+	err := t.get(ctx, "/find/"+id, map[string]string{"external_source": "imdb_id"}, result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (t *TMDB) get(ctx context.Context, path string, params map[string]string, result any) error {
+	resp, err := t.client.R().
+		SetContext(ctx).
+		SetQueryParams(params).
+		SetResult(result).
+		Get(path)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode() == http.StatusNotFound {
+		return metadata.ErrNotFound
+	}
+
+	if resp.IsError() {
+		return fmt.Errorf("error response from tmdb: %s", resp.Status())
+	}
+
+	return nil
+}
+
+func titles(alternatives []alternativeTitle) []string {
+	result := make([]string, 0, len(alternatives))
+	for _, alternative := range alternatives {
+		result = append(result, alternative.Title)
+	}
+
+	return result
+}
+
+func parseYear(date string) int {
+	year, _ := strconv.Atoi(strings.SplitN(date, "-", 2)[0])
+	return year
+}
+
+func parseIMDBID(id string) uint {
+	imdbID, _ := strconv.Atoi(strings.TrimPrefix(id, "tt"))
+	return uint(imdbID)
+}
@@ -0,0 +1,258 @@
+package torrentmeta
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/zeebo/bencode"
+)
+
+// Peer wire protocol (BEP 3) with the extension protocol (BEP 10) and the
+// metadata extension (BEP 9).
+const (
+	protocol = "BitTorrent protocol"
+
+	msgExtended = 20
+
+	extHandshake = 0
+	// utMetadataID is the id peers must use when sending us ut_metadata messages.
+	utMetadataID = 1
+
+	metadataRequest = 0
+	metadataData    = 1
+	metadataReject  = 2
+
+	metadataPieceSize = 16 * 1024
+	// maxMetadataSize guards against peers announcing absurd sizes.
+	maxMetadataSize = 10 * 1024 * 1024
+	maxMessageSize  = metadataPieceSize + 1024
+
+	// handshakeTimeout bounds dialing a peer and the BitTorrent handshake, so
+	// dead or silent peers give their slot to the next ones quickly.
+	handshakeTimeout = 3 * time.Second
+)
+
+var errNoMetadataSupport = errors.New("torrentmeta: peer doesn't support ut_metadata")
+
+type extendedHandshake struct {
+	M            map[string]int `bencode:"m"`
+	MetadataSize int            `bencode:"metadata_size,omitempty"`
+}
+
+type metadataMessage struct {
+	MsgType   int `bencode:"msg_type"`
+	Piece     int `bencode:"piece"`
+	TotalSize int `bencode:"total_size,omitempty"`
+}
+
+// fetchFromPeer downloads the info dictionary of the torrent from one peer.
+// The caller verifies it against the info hash.
+func fetchFromPeer(ctx context.Context, addr string, infoHash [20]byte, peerID [20]byte) ([]byte, error) {
+	conn, err := (&net.Dialer{Timeout: handshakeTimeout}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	// unblock reads when the context is cancelled early, e.g. another peer won
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	handshakeDeadline := time.Now().Add(handshakeTimeout)
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(handshakeDeadline) {
+		handshakeDeadline = deadline
+	}
+	_ = conn.SetDeadline(handshakeDeadline)
+
+	if err := handshake(conn, infoHash, peerID); err != nil {
+		return nil, err
+	}
+
+	deadline, _ := ctx.Deadline()
+	_ = conn.SetDeadline(deadline)
+
+	err = writeExtended(conn, extHandshake, extendedHandshake{
+		M: map[string]int{"ut_metadata": utMetadataID},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var metadata []byte
+	var done []bool
+	var peerMetadataID, received int
+	for {
+		id, payload, err := readMessage(conn)
+		if err != nil {
+			return nil, err
+		}
+
+		if id != msgExtended || len(payload) == 0 {
+			continue
+		}
+
+		switch payload[0] {
+		case extHandshake:
+			h := extendedHandshake{}
+			if err := bencode.DecodeBytes(payload[1:], &h); err != nil {
+				return nil, err
+			}
+
+			peerMetadataID = h.M["ut_metadata"]
+			if peerMetadataID == 0 || h.MetadataSize <= 0 {
+				return nil, errNoMetadataSupport
+			}
+
+			if h.MetadataSize > maxMetadataSize {
+				return nil, fmt.Errorf("torrentmeta: metadata too large: %d", h.MetadataSize)
+			}
+
+			if metadata != nil {
+				continue
+			}
+
+			metadata = make([]byte, h.MetadataSize)
+			done = make([]bool, (h.MetadataSize+metadataPieceSize-1)/metadataPieceSize)
+			for piece := 0; piece*metadataPieceSize < h.MetadataSize; piece++ {
+				err := writeExtended(conn, byte(peerMetadataID), metadataMessage{MsgType: metadataRequest, Piece: piece})
+				if err != nil {
+					return nil, err
+				}
+			}
+		case utMetadataID:
+			if metadata == nil {
+				continue
+			}
+
+			piece, err := copyPiece(metadata, payload[1:])
+			if err != nil {
+				return nil, err
+			}
+
+			if piece >= 0 && !done[piece] {
+				done[piece] = true
+				received++
+			}
+
+			if received == len(done) {
+				return metadata, nil
+			}
+		}
+	}
+}
+
+// copyPiece copies a ut_metadata data message into metadata and returns the
+// piece number, or -1 for messages that carry no data.
+func copyPiece(metadata []byte, payload []byte) (int, error) {
+	msg := metadataMessage{}
+	decoder := bencode.NewDecoder(bytes.NewReader(payload))
+	if err := decoder.Decode(&msg); err != nil {
+		return 0, err
+	}
+
+	switch msg.MsgType {
+	case metadataData:
+	case metadataReject:
+		return 0, fmt.Errorf("torrentmeta: peer rejected piece %d", msg.Piece)
+	default:
+		return -1, nil
+	}
+
+	data := payload[decoder.BytesParsed():]
+	offset := msg.Piece * metadataPieceSize
+	if msg.Piece < 0 || offset >= len(metadata) || offset+len(data) > len(metadata) {
+		return 0, fmt.Errorf("torrentmeta: invalid piece %d", msg.Piece)
+	}
+
+	if offset+len(data) != len(metadata) && len(data) != metadataPieceSize {
+		return 0, fmt.Errorf("torrentmeta: invalid size of piece %d", msg.Piece)
+	}
+
+	copy(metadata[offset:], data)
+	return msg.Piece, nil
+}
+
+func handshake(conn net.Conn, infoHash [20]byte, peerID [20]byte) error {
+	msg := make([]byte, 0, 68)
+	msg = append(msg, byte(len(protocol)))
+	msg = append(msg, protocol...)
+	reserved := make([]byte, 8)
+	reserved[5] |= 0x10 // extension protocol
+	msg = append(msg, reserved...)
+	msg = append(msg, infoHash[:]...)
+	msg = append(msg, peerID[:]...)
+	if _, err := conn.Write(msg); err != nil {
+		return err
+	}
+
+	resp := make([]byte, 68)
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		return err
+	}
+
+	if resp[0] != byte(len(protocol)) || string(resp[1:20]) != protocol {
+		return errors.New("torrentmeta: invalid handshake")
+	}
+
+	if !bytes.Equal(resp[28:48], infoHash[:]) {
+		return errors.New("torrentmeta: peer has another torrent")
+	}
+
+	if resp[25]&0x10 == 0 {
+		return errNoMetadataSupport
+	}
+
+	return nil
+}
+
+func writeExtended(conn net.Conn, extID byte, v any) error {
+	payload, err := bencode.EncodeBytes(v)
+	if err != nil {
+		return err
+	}
+
+	msg := make([]byte, 6, 6+len(payload))
+	binary.BigEndian.PutUint32(msg[0:4], uint32(2+len(payload)))
+	msg[4] = msgExtended
+	msg[5] = extID
+	msg = append(msg, payload...)
+	_, err = conn.Write(msg)
+	return err
+}
+
+// readMessage returns the id and payload of the next message, keep-alives
+// are skipped.
+func readMessage(conn net.Conn) (byte, []byte, error) {
+	for {
+		header := make([]byte, 4)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return 0, nil, err
+		}
+
+		length := binary.BigEndian.Uint32(header)
+		if length == 0 {
+			continue
+		}
+
+		if length > maxMessageSize {
+			// bitfields of huge torrents are skipped rather than read in
+			if _, err := io.CopyN(io.Discard, conn, int64(length)); err != nil {
+				return 0, nil, err
+			}
+			continue
+		}
+
+		msg := make([]byte, length)
+		if _, err := io.ReadFull(conn, msg); err != nil {
+			return 0, nil, err
+		}
+
+		return msg[0], msg[1:], nil
+	}
+}
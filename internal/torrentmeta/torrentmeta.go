@@ -0,0 +1,292 @@
+// Package torrentmeta downloads the info dictionary of magnet links from
+// BitTorrent peers (BEP 9), so magnet-only results get a file list without
+// a debrid service.
+package torrentmeta
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/dbytex91/streamx/internal/prowlarr"
+	"github.com/dbytex91/streamx/internal/store"
+	"github.com/dbytex91/streamx/internal/tracker"
+)
+
+const (
+	defaultTimeout     = 10 * time.Second
+	defaultConcurrency = 4
+	// parallelPeers is the number of peers asked at the same time per torrent.
+	parallelPeers = 8
+	// maxPeers bounds the peers tried per torrent.
+	maxPeers = 100
+	// storeExpiry keeps info dictionaries for a week, they never change.
+	storeExpiry = 7 * 24 * time.Hour
+	// pruneInterval is how often expired info dictionaries are deleted.
+	pruneInterval = time.Hour
+	storeBucket   = "torrent_info"
+)
+
+var ErrNoPeers = errors.New("torrentmeta: no peer sent the metadata")
+
+type Fetcher struct {
+	peerID      [20]byte
+	timeout     time.Duration
+	concurrency chan struct{}
+	store       *store.Store
+
+	pruneMu    sync.Mutex
+	lastPruned time.Time
+}
+
+// storedInfo is an info dictionary kept in the store.
+type storedInfo struct {
+	Bytes   []byte    `json:"bytes"`
+	Expires time.Time `json:"expires"`
+}
+
+type Option func(*Fetcher)
+
+// WithTimeout bounds the whole lookup of one torrent, trackers included.
+func WithTimeout(timeout time.Duration) Option {
+	return func(f *Fetcher) {
+		f.timeout = timeout
+	}
+}
+
+// WithConcurrency caps the number of torrents resolved at the same time.
+func WithConcurrency(concurrency int) Option {
+	return func(f *Fetcher) {
+		f.concurrency = make(chan struct{}, concurrency)
+	}
+}
+
+// WithStore keeps the info dictionaries in s by info hash. They take up to
+// megabytes for big packs, which in-memory caches drop.
+func WithStore(s *store.Store) Option {
+	return func(f *Fetcher) {
+		f.store = s
+	}
+}
+
+func New(opts ...Option) *Fetcher {
+	f := &Fetcher{
+		timeout:     defaultTimeout,
+		concurrency: make(chan struct{}, defaultConcurrency),
+	}
+
+	copy(f.peerID[:], "-SX0200-")
+	_, _ = rand.Read(f.peerID[8:])
+
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	return f
+}
+
+// Cached returns the info dictionary of the torrent if it was fetched before.
+func (f *Fetcher) Cached(infoHash [20]byte) (*prowlarr.Info, bool) {
+	if f.store == nil {
+		return nil, false
+	}
+
+	stored := storedInfo{}
+	if err := f.store.Get(storeBucket, storeKey(infoHash), &stored); err != nil || time.Now().After(stored.Expires) {
+		return nil, false
+	}
+
+	info, err := prowlarr.NewInfo(stored.Bytes, true, true)
+	if err != nil {
+		return nil, false
+	}
+
+	return info, true
+}
+
+// Store caches an info dictionary obtained elsewhere, e.g. from a .torrent file.
+func (f *Fetcher) Store(info *prowlarr.Info) {
+	if f.store == nil || len(info.Bytes) == 0 {
+		return
+	}
+
+	stored := storedInfo{Bytes: info.Bytes, Expires: time.Now().Add(storeExpiry)}
+	if err := f.store.Put(storeBucket, storeKey(info.Hash), stored); err != nil {
+		slog.Warn("Couldn't store metadata", "info_hash", hex.EncodeToString(info.Hash[:]), "error", err)
+	}
+
+	f.prune()
+}
+
+// prune deletes the expired info dictionaries, at most once per pruneInterval.
+func (f *Fetcher) prune() {
+	f.pruneMu.Lock()
+	if time.Since(f.lastPruned) < pruneInterval {
+		f.pruneMu.Unlock()
+		return
+	}
+	f.lastPruned = time.Now()
+	f.pruneMu.Unlock()
+
+	now := time.Now()
+	err := f.store.DeleteFunc(storeBucket, func(_ string, raw []byte) bool {
+		stored := storedInfo{}
+		return json.Unmarshal(raw, &stored) != nil || now.After(stored.Expires)
+	})
+	if err != nil {
+		slog.Warn("Couldn't prune metadata", "error", err)
+	}
+}
+
+// Fetch returns the info dictionary of the magnet, asking the peers listed in
+// the magnet and the peers returned by its trackers.
+func (f *Fetcher) Fetch(ctx context.Context, magnet *prowlarr.Magnet) (*prowlarr.Info, error) {
+	if info, ok := f.Cached(magnet.InfoHash); ok {
+		return info, nil
+	}
+
+	select {
+	case f.concurrency <- struct{}{}:
+		defer func() { <-f.concurrency }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, f.timeout)
+	defer cancel()
+
+	peers := f.discoverPeers(ctx, magnet)
+	metadata, err := f.fetchFromPeers(ctx, peers, magnet.InfoHash)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := prowlarr.NewInfo(metadata, true, true)
+	if err != nil {
+		return nil, err
+	}
+
+	f.Store(info)
+	return info, nil
+}
+
+// discoverPeers streams the peers of the magnet, the ones it lists come first.
+// The channel is closed once every tracker answered or the context is done.
+func (f *Fetcher) discoverPeers(ctx context.Context, magnet *prowlarr.Magnet) <-chan string {
+	peers := make(chan string, maxPeers)
+	seen := map[string]bool{}
+	mu := sync.Mutex{}
+	send := func(peer string) {
+		mu.Lock()
+		defer mu.Unlock()
+		if seen[peer] || len(seen) >= maxPeers {
+			return
+		}
+
+		seen[peer] = true
+		peers <- peer
+	}
+
+	for _, peer := range magnet.Peers {
+		send(peer)
+	}
+
+	wg := sync.WaitGroup{}
+	for _, trackerURL := range tracker.Trackers(magnet.Trackers) {
+		wg.Add(1)
+		go func(trackerURL string) {
+			defer wg.Done()
+			found, err := tracker.Announce(ctx, trackerURL, magnet.InfoHash, f.peerID)
+			if err != nil {
+				slog.DebugContext(ctx, "Announce failed", "tracker", trackerURL, "error", err)
+				return
+			}
+
+			for _, peer := range found {
+				send(peer)
+			}
+		}(trackerURL)
+	}
+
+	go func() {
+		wg.Wait()
+		close(peers)
+	}()
+
+	return peers
+}
+
+// fetchFromPeers asks up to parallelPeers peers at once and returns the first
+// metadata matching the info hash.
+func (f *Fetcher) fetchFromPeers(ctx context.Context, peers <-chan string, infoHash [20]byte) ([]byte, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	result := make(chan []byte, 1)
+	slots := make(chan struct{}, parallelPeers)
+	wg := sync.WaitGroup{}
+
+loop:
+	for {
+		select {
+		case peer, ok := <-peers:
+			if !ok {
+				break loop
+			}
+
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				break loop
+			}
+
+			wg.Add(1)
+			go func(peer string) {
+				defer wg.Done()
+				defer func() { <-slots }()
+
+				metadata, err := fetchFromPeer(ctx, peer, infoHash, f.peerID)
+				if err != nil {
+					return
+				}
+
+				if sha1.Sum(metadata) != infoHash {
+					slog.DebugContext(ctx, "Peer sent metadata not matching the info hash", "peer", peer, "info_hash", hex.EncodeToString(infoHash[:]))
+					return
+				}
+
+				select {
+				case result <- metadata:
+					cancel()
+				default:
+				}
+			}(peer)
+		case metadata := <-result:
+			return metadata, nil
+		case <-ctx.Done():
+			break loop
+		}
+	}
+
+	wg.Wait()
+	select {
+	case metadata := <-result:
+		return metadata, nil
+	default:
+		if err := ctx.Err(); err != nil && !errors.Is(err, context.Canceled) {
+			return nil, err
+		}
+
+		return nil, ErrNoPeers
+	}
+}
+
+func storeKey(infoHash [20]byte) string {
+	return hex.EncodeToString(infoHash[:])
+}
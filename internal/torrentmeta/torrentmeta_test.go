@@ -0,0 +1,230 @@
+package torrentmeta_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zeebo/bencode"
+
+	"github.com/dbytex91/streamx/internal/prowlarr"
+	"github.com/dbytex91/streamx/internal/store"
+	"github.com/dbytex91/streamx/internal/torrentmeta"
+)
+
+const pieceLength = 256 * 1024
+
+type testFile struct {
+	Length int64    `bencode:"length"`
+	Path   []string `bencode:"path"`
+}
+
+type testInfo struct {
+	Files       []testFile `bencode:"files"`
+	Name        string     `bencode:"name"`
+	PieceLength int        `bencode:"piece length"`
+	Pieces      []byte     `bencode:"pieces"`
+}
+
+// seasonPackInfo builds an info dictionary large enough to span several
+// metadata pieces.
+func seasonPackInfo(t *testing.T) []byte {
+	return packInfo(t, 400)
+}
+
+func packInfo(t *testing.T, episodes int) []byte {
+	info := testInfo{Name: "Show.S01.1080p.WEB-DL.x264-GROUP", PieceLength: pieceLength}
+	var total int64
+	for episode := 1; episode <= episodes; episode++ {
+		length := int64(1024*1024 + episode)
+		info.Files = append(info.Files, testFile{
+			Length: length,
+			Path:   []string{fmt.Sprintf("Show.S01E%03d.1080p.WEB-DL.x264-GROUP.mkv", episode)},
+		})
+		total += length
+	}
+	info.Pieces = make([]byte, (total+pieceLength-1)/pieceLength*sha1.Size)
+
+	raw, err := bencode.EncodeBytes(info)
+	require.NoError(t, err)
+	require.Greater(t, len(raw), 2*16*1024)
+	return raw
+}
+
+// seed serves metadata to every peer connecting to the returned address.
+func seed(t *testing.T, metadata []byte) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	infoHash := sha1.Sum(metadata)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go servePeer(conn, infoHash, metadata)
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+// silentPeer accepts connections and never answers the handshake.
+func silentPeer(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { conn.Close() })
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func servePeer(conn net.Conn, infoHash [20]byte, metadata []byte) {
+	defer conn.Close()
+
+	handshake := make([]byte, 68)
+	if _, err := io.ReadFull(conn, handshake); err != nil {
+		return
+	}
+
+	reply := append([]byte{19}, "BitTorrent protocol"...)
+	reply = append(reply, 0, 0, 0, 0, 0, 0x10, 0, 0)
+	reply = append(reply, infoHash[:]...)
+	reply = append(reply, bytes.Repeat([]byte{'p'}, 20)...)
+	conn.Write(reply)
+
+	const seederMetadataID = 3
+	var clientMetadataID int
+	for {
+		header := make([]byte, 4)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		msg := make([]byte, binary.BigEndian.Uint32(header))
+		if _, err := io.ReadFull(conn, msg); err != nil {
+			return
+		}
+		if msg[0] != 20 {
+			continue
+		}
+
+		switch msg[1] {
+		case 0:
+			h := struct {
+				M map[string]int `bencode:"m"`
+			}{}
+			bencode.DecodeBytes(msg[2:], &h)
+			clientMetadataID = h.M["ut_metadata"]
+			writeExtended(conn, 0, map[string]any{
+				"m":             map[string]int{"ut_metadata": seederMetadataID},
+				"metadata_size": len(metadata),
+			}, nil)
+		case seederMetadataID:
+			req := struct {
+				Piece int `bencode:"piece"`
+			}{}
+			bencode.DecodeBytes(msg[2:], &req)
+			start := req.Piece * 16 * 1024
+			end := min(start+16*1024, len(metadata))
+			writeExtended(conn, byte(clientMetadataID), map[string]int{
+				"msg_type":   1,
+				"piece":      req.Piece,
+				"total_size": len(metadata),
+			}, metadata[start:end])
+		}
+	}
+}
+
+func writeExtended(conn net.Conn, extID byte, v any, data []byte) {
+	payload, _ := bencode.EncodeBytes(v)
+	payload = append(payload, data...)
+	msg := make([]byte, 6)
+	binary.BigEndian.PutUint32(msg, uint32(2+len(payload)))
+	msg[4] = 20
+	msg[5] = extID
+	conn.Write(append(msg, payload...))
+}
+
+func TestFetchFromSeeder(t *testing.T) {
+	metadata := seasonPackInfo(t)
+	addr := seed(t, metadata)
+	fetcher := torrentmeta.New(torrentmeta.WithTimeout(5*time.Second), torrentmeta.WithStore(openStore(t)))
+
+	magnet := &prowlarr.Magnet{InfoHash: sha1.Sum(metadata), Peers: []string{addr}}
+	info, err := fetcher.Fetch(context.Background(), magnet)
+	require.NoError(t, err)
+	assert.Equal(t, "Show.S01.1080p.WEB-DL.x264-GROUP", info.Name)
+	require.Len(t, info.Files, 400)
+	assert.Equal(t, "Show.S01.1080p.WEB-DL.x264-GROUP/Show.S01E042.1080p.WEB-DL.x264-GROUP.mkv", info.Files[41].Path)
+
+	cached, ok := fetcher.Cached(magnet.InfoHash)
+	require.True(t, ok)
+	assert.Equal(t, info.Hash, cached.Hash)
+}
+
+func TestFetchSkipsSilentPeers(t *testing.T) {
+	metadata := seasonPackInfo(t)
+	peers := []string{}
+	for range 8 {
+		peers = append(peers, silentPeer(t))
+	}
+	peers = append(peers, seed(t, metadata))
+
+	// without a handshake timeout the silent peers hold every slot until the end
+	fetcher := torrentmeta.New(torrentmeta.WithTimeout(20 * time.Second))
+	start := time.Now()
+	_, err := fetcher.Fetch(context.Background(), &prowlarr.Magnet{InfoHash: sha1.Sum(metadata), Peers: peers})
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 10*time.Second)
+}
+
+func TestStoreLargeInfo(t *testing.T) {
+	// a few megabytes, far over the largest entry of the addon's cache
+	metadata := packInfo(t, 20000)
+	require.Greater(t, len(metadata), 2*1024*1024)
+	info, err := prowlarr.NewInfo(metadata, true, true)
+	require.NoError(t, err)
+
+	fetcher := torrentmeta.New(torrentmeta.WithStore(openStore(t)))
+	fetcher.Store(info)
+
+	cached, ok := fetcher.Cached(info.Hash)
+	require.True(t, ok)
+	assert.Len(t, cached.Files, 20000)
+}
+
+func TestFetchRejectsWrongMetadata(t *testing.T) {
+	addr := seed(t, seasonPackInfo(t))
+	fetcher := torrentmeta.New(torrentmeta.WithTimeout(2 * time.Second))
+
+	magnet := &prowlarr.Magnet{InfoHash: sha1.Sum([]byte("another torrent")), Peers: []string{addr}}
+	_, err := fetcher.Fetch(context.Background(), magnet)
+	assert.Error(t, err)
+}
+
+func openStore(t *testing.T) *store.Store {
+	s, err := store.Open(filepath.Join(t.TempDir(), "streamx.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
@@ -0,0 +1,63 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/go-resty/resty/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var attributeRequestID = attribute.Key("streamx.request_id")
+
+type spanKey struct{}
+
+// InstrumentResty starts a client span for each call of client from the
+// context of the request and sends the trace along to the server called.
+func InstrumentResty(client *resty.Client, service string) *resty.Client {
+	return client.
+		OnBeforeRequest(func(_ *resty.Client, req *resty.Request) error {
+			ctx, span := otel.Tracer(instrumentationName).Start(req.Context(), service+" "+req.Method,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(semconv.HTTPRequestMethodKey.String(req.Method)),
+			)
+			otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+			req.SetContext(context.WithValue(ctx, spanKey{}, span))
+			return nil
+		}).
+		OnAfterResponse(func(_ *resty.Client, resp *resty.Response) error {
+			if span, ok := resp.Request.Context().Value(spanKey{}).(trace.Span); ok {
+				span.SetAttributes(
+					semconv.ServerAddress(host(resp.Request.URL)),
+					semconv.HTTPResponseStatusCode(resp.StatusCode()),
+				)
+				if resp.StatusCode() >= http.StatusBadRequest {
+					span.SetStatus(codes.Error, resp.Status())
+				}
+				span.End()
+			}
+			return nil
+		}).
+		OnError(func(req *resty.Request, err error) {
+			// ending a span twice is a no-op, it may have ended with the response
+			if span, ok := req.Context().Value(spanKey{}).(trace.Span); ok {
+				End(span, err)
+			}
+		})
+}
+
+// host keeps only the host of a URL, paths and queries may carry API keys.
+func host(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+
+	return u.Host
+}
@@ -0,0 +1,107 @@
+// Package tracing exports OpenTelemetry traces of requests over OTLP. Without
+// an endpoint the global no-op tracer is kept, spans cost next to nothing.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/dbytex91/streamx/internal/logging"
+)
+
+const instrumentationName = "github.com/dbytex91/streamx"
+
+type Config struct {
+	// Endpoint is the OTLP/HTTP endpoint of the collector, e.g.
+	// http://localhost:4318. Tracing is disabled when empty.
+	Endpoint string
+	// SampleRatio is the share of requests traced, between 0 and 1.
+	SampleRatio float64
+	Version     string
+}
+
+// Setup installs the global tracer provider and returns the function flushing
+// pending spans on shutdown.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if cfg.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Trim(endpoint.Path, "/") == "" {
+		// like OTEL_EXPORTER_OTLP_ENDPOINT, a base URL gets the traces path
+		endpoint.Path = "/v1/traces"
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint.String()))
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName("streamx"),
+		semconv.ServiceVersion(cfg.Version),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Start starts a span named name as a child of the span in ctx.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End ends span, marking it failed when err is set.
+func End(span trace.Span, err error) {
+	if err != nil {
+		RecordError(span, err)
+		span.SetStatus(codes.Error, errorMessage(err))
+	}
+	span.End()
+}
+
+// RecordError adds the exception event of err to span. The message keeps only
+// the host of URLs and is redacted, queries carry API keys.
+func RecordError(span trace.Span, err error, attrs ...attribute.KeyValue) {
+	attrs = append(attrs,
+		semconv.ExceptionType(fmt.Sprintf("%T", err)),
+		semconv.ExceptionMessage(errorMessage(err)),
+	)
+	span.AddEvent("exception", trace.WithAttributes(attrs...))
+}
+
+func errorMessage(err error) string {
+	urlErr := &url.Error{}
+	if errors.As(err, &urlErr) {
+		return logging.Redact(urlErr.Op + " " + host(urlErr.URL) + ": " + urlErr.Err.Error())
+	}
+
+	return logging.Redact(err.Error())
+}
@@ -0,0 +1,39 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/dbytex91/streamx/internal/tracing"
+)
+
+func TestEndKeepsKeysOutOfErrors(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	_, span := provider.Tracer("test").Start(context.Background(), "search")
+	tracing.End(span, &url.Error{
+		Op:  "Get",
+		URL: "http://prowlarr:9696/1/download?apikey=SECRET&link=abc",
+		Err: errors.New("dial tcp: i/o timeout"),
+	})
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "Get prowlarr:9696: dial tcp: i/o timeout", spans[0].Status().Description)
+	require.Len(t, spans[0].Events(), 1)
+	for _, attr := range spans[0].Events()[0].Attributes {
+		assert.NotContains(t, attr.Value.Emit(), "SECRET")
+	}
+
+	_, span = provider.Tracer("test").Start(context.Background(), "metadata")
+	tracing.End(span, errors.New("request failed: https://api.themoviedb.org/3/movie/1?api_key=SECRET"))
+	assert.NotContains(t, recorder.Ended()[1].Status().Description, "SECRET")
+}
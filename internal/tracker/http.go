@@ -0,0 +1,136 @@
+package tracker
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/zeebo/bencode"
+)
+
+// maxResponseSize bounds what is read from a tracker, announce and scrape
+// answers are a few kilobytes at most.
+const maxResponseSize = 1 << 20
+
+type announceResponse struct {
+	FailureReason string             `bencode:"failure reason"`
+	Peers         bencode.RawMessage `bencode:"peers"`
+	Peers6        bencode.RawMessage `bencode:"peers6"`
+}
+
+type dictPeer struct {
+	IP   string `bencode:"ip"`
+	Port int    `bencode:"port"`
+}
+
+func announceHTTP(ctx context.Context, u *url.URL, infoHash [20]byte, peerID [20]byte) ([]string, error) {
+	query := u.Query()
+	query.Set("info_hash", string(infoHash[:]))
+	query.Set("peer_id", string(peerID[:]))
+	query.Set("port", strconv.Itoa(DefaultPort))
+	query.Set("uploaded", "0")
+	query.Set("downloaded", "0")
+	// a regular announce without event, so StreamX isn't counted as a leecher
+	// in the numbers it scrapes afterwards
+	query.Set("left", "0")
+	query.Set("compact", "1")
+	query.Set("numwant", strconv.Itoa(numWant))
+
+	announceURL := *u
+	announceURL.RawQuery = query.Encode()
+
+	body, err := get(ctx, announceURL.String())
+	if err != nil {
+		return nil, err
+	}
+
+	resp := announceResponse{}
+	if err := bencode.DecodeBytes(body, &resp); err != nil {
+		return nil, err
+	}
+
+	if resp.FailureReason != "" {
+		return nil, fmt.Errorf("tracker: %s", resp.FailureReason)
+	}
+
+	peers, err := parsePeers(resp.Peers, net.IPv4len)
+	if err != nil {
+		return nil, err
+	}
+
+	peers6, err := parsePeers(resp.Peers6, net.IPv6len)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(peers, peers6...), nil
+}
+
+// parsePeers reads the compact (BEP 23) or dictionary peer list.
+func parsePeers(raw bencode.RawMessage, ipLen int) ([]string, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	if raw[0] == 'l' {
+		list := []dictPeer{}
+		if err := bencode.DecodeBytes(raw, &list); err != nil {
+			return nil, err
+		}
+
+		peers := make([]string, 0, len(list))
+		for _, p := range list {
+			peers = append(peers, net.JoinHostPort(p.IP, strconv.Itoa(p.Port)))
+		}
+
+		return peers, nil
+	}
+
+	var compact string
+	if err := bencode.DecodeBytes(raw, &compact); err != nil {
+		return nil, err
+	}
+
+	return parseCompactPeers([]byte(compact), ipLen)
+}
+
+func parseCompactPeers(b []byte, ipLen int) ([]string, error) {
+	size := ipLen + 2
+	if len(b)%size != 0 {
+		return nil, errors.New("tracker: invalid compact peer list")
+	}
+
+	peers := make([]string, 0, len(b)/size)
+	for i := 0; i < len(b); i += size {
+		ip := net.IP(b[i : i+ipLen])
+		port := binary.BigEndian.Uint16(b[i+ipLen : i+size])
+		peers = append(peers, net.JoinHostPort(ip.String(), strconv.Itoa(int(port))))
+	}
+
+	return peers, nil
+}
+
+func get(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("tracker: unexpected status %s", resp.Status)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+}
@@ -0,0 +1,122 @@
+package tracker
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/zeebo/bencode"
+)
+
+var ErrScrapeUnsupported = errors.New("tracker: scrape not supported")
+
+// Stats are the swarm numbers a tracker reports for a torrent.
+type Stats struct {
+	Seeders   uint
+	Leechers  uint
+	Completed uint
+}
+
+type scrapeResponse struct {
+	FailureReason string                 `bencode:"failure reason"`
+	Files         map[string]scrapeStats `bencode:"files"`
+}
+
+type scrapeStats struct {
+	Complete   uint `bencode:"complete"`
+	Incomplete uint `bencode:"incomplete"`
+	Downloaded uint `bencode:"downloaded"`
+}
+
+// Scrape asks the tracker for the seeders and leechers of the torrent, over
+// HTTP (BEP 48) or UDP (BEP 15).
+func Scrape(ctx context.Context, trackerURL string, infoHash [20]byte) (*Stats, error) {
+	u, err := url.Parse(trackerURL)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		return scrapeHTTP(ctx, u, infoHash)
+	case "udp":
+		return scrapeUDP(ctx, u.Host, infoHash)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedTracker, u.Scheme)
+	}
+}
+
+// scrapeURL derives the scrape URL from the announce URL, only trackers whose
+// last path segment starts with "announce" support it.
+func scrapeURL(u *url.URL) (*url.URL, error) {
+	i := strings.LastIndex(u.Path, "/")
+	if i < 0 || !strings.HasPrefix(u.Path[i+1:], "announce") {
+		return nil, ErrScrapeUnsupported
+	}
+
+	scrape := *u
+	scrape.Path = u.Path[:i+1] + "scrape" + strings.TrimPrefix(u.Path[i+1:], "announce")
+	return &scrape, nil
+}
+
+func scrapeHTTP(ctx context.Context, u *url.URL, infoHash [20]byte) (*Stats, error) {
+	scrape, err := scrapeURL(u)
+	if err != nil {
+		return nil, err
+	}
+
+	query := scrape.Query()
+	query.Set("info_hash", string(infoHash[:]))
+	scrape.RawQuery = query.Encode()
+
+	body, err := get(ctx, scrape.String())
+	if err != nil {
+		return nil, err
+	}
+
+	resp := scrapeResponse{}
+	if err := bencode.DecodeBytes(body, &resp); err != nil {
+		return nil, err
+	}
+
+	if resp.FailureReason != "" {
+		return nil, fmt.Errorf("tracker: %s", resp.FailureReason)
+	}
+
+	stats, ok := resp.Files[string(infoHash[:])]
+	if !ok {
+		return nil, errors.New("tracker: torrent not in scrape response")
+	}
+
+	return &Stats{
+		Seeders:   stats.Complete,
+		Leechers:  stats.Incomplete,
+		Completed: stats.Downloaded,
+	}, nil
+}
+
+func scrapeUDP(ctx context.Context, host string, infoHash [20]byte) (*Stats, error) {
+	c, err := dialUDP(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	req := make([]byte, 36)
+	binary.BigEndian.PutUint64(req[0:8], c.connectionID)
+	copy(req[16:36], infoHash[:])
+
+	resp, err := c.roundTrip(actionScrape, req, 20)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Stats{
+		Seeders:   uint(binary.BigEndian.Uint32(resp[8:12])),
+		Completed: uint(binary.BigEndian.Uint32(resp[12:16])),
+		Leechers:  uint(binary.BigEndian.Uint32(resp[16:20])),
+	}, nil
+}
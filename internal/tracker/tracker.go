@@ -0,0 +1,56 @@
+// Package tracker talks to BitTorrent trackers over HTTP (BEP 3) and UDP (BEP 15).
+package tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	// DefaultPort is announced to trackers, nothing listens on it as StreamX
+	// only downloads metadata.
+	DefaultPort = 6881
+	numWant     = 50
+)
+
+var ErrUnsupportedTracker = errors.New("tracker: unsupported scheme")
+
+// Announce asks the tracker for peers of the torrent and returns them as
+// "host:port" addresses.
+func Announce(ctx context.Context, trackerURL string, infoHash [20]byte, peerID [20]byte) ([]string, error) {
+	u, err := url.Parse(trackerURL)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		return announceHTTP(ctx, u, infoHash, peerID)
+	case "udp":
+		return announceUDP(ctx, u.Host, infoHash, peerID)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedTracker, u.Scheme)
+	}
+}
+
+// Trackers flattens announce tiers into a list of unique tracker URLs.
+func Trackers(tiers [][]string) []string {
+	trackers := []string{}
+	seen := map[string]bool{}
+	for _, tier := range tiers {
+		for _, tracker := range tier {
+			tracker = strings.TrimSpace(tracker)
+			if tracker == "" || seen[tracker] {
+				continue
+			}
+
+			seen[tracker] = true
+			trackers = append(trackers, tracker)
+		}
+	}
+
+	return trackers
+}
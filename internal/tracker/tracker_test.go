@@ -0,0 +1,99 @@
+package tracker_test
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zeebo/bencode"
+
+	"github.com/dbytex91/streamx/internal/tracker"
+)
+
+var infoHash = [20]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20}
+
+func TestHTTPAnnounceAndScrape(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, string(infoHash[:]), r.URL.Query().Get("info_hash"))
+		switch r.URL.Path {
+		case "/tk/announce":
+			assert.Equal(t, "0", r.URL.Query().Get("left"))
+			assert.False(t, r.URL.Query().Has("event"))
+			body, _ := bencode.EncodeBytes(map[string]any{
+				"interval": 1800,
+				"peers":    string([]byte{127, 0, 0, 1, 0x1a, 0xe1, 10, 0, 0, 2, 0x1a, 0xe2}),
+			})
+			w.Write(body)
+		case "/tk/scrape":
+			body, _ := bencode.EncodeBytes(map[string]any{
+				"files": map[string]any{
+					string(infoHash[:]): map[string]int{"complete": 42, "incomplete": 7, "downloaded": 100},
+				},
+			})
+			w.Write(body)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	peers, err := tracker.Announce(ctx, server.URL+"/tk/announce", infoHash, [20]byte{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"127.0.0.1:6881", "10.0.0.2:6882"}, peers)
+
+	stats, err := tracker.Scrape(ctx, server.URL+"/tk/announce", infoHash)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.Stats{Seeders: 42, Leechers: 7, Completed: 100}, stats)
+
+	_, err = tracker.Scrape(ctx, server.URL+"/tk/other", infoHash)
+	assert.ErrorIs(t, err, tracker.ErrScrapeUnsupported)
+}
+
+func TestUDPScrape(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			req := buf[:n]
+			resp := make([]byte, 20)
+			copy(resp[0:8], req[8:16]) // action and transaction id
+			switch binary.BigEndian.Uint32(req[8:12]) {
+			case 0:
+				binary.BigEndian.PutUint64(resp[8:16], 0xc0ffee)
+				resp = resp[:16]
+			case 2:
+				if binary.BigEndian.Uint64(req[0:8]) != 0xc0ffee || string(req[16:36]) != string(infoHash[:]) {
+					return
+				}
+				binary.BigEndian.PutUint32(resp[8:12], 12)
+				binary.BigEndian.PutUint32(resp[12:16], 30)
+				binary.BigEndian.PutUint32(resp[16:20], 3)
+			}
+			conn.WriteTo(resp, addr)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	stats, err := tracker.Scrape(ctx, "udp://"+conn.LocalAddr().String()+"/announce", infoHash)
+	require.NoError(t, err)
+	assert.Equal(t, &tracker.Stats{Seeders: 12, Leechers: 3, Completed: 30}, stats)
+}
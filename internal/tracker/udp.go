@@ -0,0 +1,125 @@
+package tracker
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// UDP tracker protocol, see https://www.bittorrent.org/beps/bep_0015.html
+const (
+	udpProtocolID = 0x41727101980
+
+	actionConnect  = 0
+	actionAnnounce = 1
+	actionScrape   = 2
+	actionError    = 3
+
+	eventNone = 0
+
+	// udpTimeout is used when the context has no deadline.
+	udpTimeout = 5 * time.Second
+)
+
+type udpConn struct {
+	conn         net.Conn
+	connectionID uint64
+}
+
+func dialUDP(ctx context.Context, host string) (*udpConn, error) {
+	conn, err := (&net.Dialer{}).DialContext(ctx, "udp", host)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(udpTimeout)
+	}
+	_ = conn.SetDeadline(deadline)
+
+	c := &udpConn{conn: conn}
+	req := make([]byte, 16)
+	binary.BigEndian.PutUint64(req[0:8], udpProtocolID)
+	resp, err := c.roundTrip(actionConnect, req, 16)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	c.connectionID = binary.BigEndian.Uint64(resp[8:16])
+	return c, nil
+}
+
+func (c *udpConn) Close() error {
+	return c.conn.Close()
+}
+
+// roundTrip fills the action and a fresh transaction id into req, sends it and
+// returns the answer once it has at least minLen bytes.
+func (c *udpConn) roundTrip(action uint32, req []byte, minLen int) ([]byte, error) {
+	transactionID := make([]byte, 4)
+	if _, err := rand.Read(transactionID); err != nil {
+		return nil, err
+	}
+
+	binary.BigEndian.PutUint32(req[8:12], action)
+	copy(req[12:16], transactionID)
+	if _, err := c.conn.Write(req); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 4096)
+	for {
+		n, err := c.conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+
+		resp := buf[:n]
+		if n < 8 || string(resp[4:8]) != string(transactionID) {
+			// stale answer to an earlier request
+			continue
+		}
+
+		switch binary.BigEndian.Uint32(resp[0:4]) {
+		case action:
+			if n < minLen {
+				return nil, errors.New("tracker: short udp response")
+			}
+			return resp, nil
+		case actionError:
+			return nil, fmt.Errorf("tracker: %s", resp[8:])
+		default:
+			return nil, errors.New("tracker: unexpected udp action")
+		}
+	}
+}
+
+func announceUDP(ctx context.Context, host string, infoHash [20]byte, peerID [20]byte) ([]string, error) {
+	c, err := dialUDP(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	req := make([]byte, 98)
+	binary.BigEndian.PutUint64(req[0:8], c.connectionID)
+	copy(req[16:36], infoHash[:])
+	copy(req[36:56], peerID[:])
+	// left and downloaded stay 0 with no event, as in announceHTTP
+	binary.BigEndian.PutUint32(req[80:84], eventNone)
+	binary.BigEndian.PutUint32(req[92:96], numWant)
+	binary.BigEndian.PutUint16(req[96:98], DefaultPort)
+
+	resp, err := c.roundTrip(actionAnnounce, req, 20)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseCompactPeers(resp[20:], net.IPv4len)
+}
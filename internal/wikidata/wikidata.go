@@ -0,0 +1,153 @@
+package wikidata
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+
+	"github.com/dbytex91/streamx/internal/tracing"
+)
+
+const (
+	// maxNames caps the number of labels and aliases kept per title, some
+	// entries have hundreds of translations.
+	maxNames = 30
+
+	namesQuery = `SELECT ?kind ?name ?lang WHERE {
+  ?item wdt:P345 "%s".
+  {
+    ?item wdt:P1476 ?name. BIND("original" AS ?kind)
+  } UNION {
+    ?item rdfs:label ?name. BIND("label" AS ?kind)
+  } UNION {
+    ?item skos:altLabel ?name. BIND("alias" AS ?kind)
+  }
+  BIND(LANG(?name) AS ?lang)
+}`
+)
+
+// kindOrder ranks the names, original titles first and aliases last.
+var kindOrder = map[string]int{"original": 0, "label": 1, "alias": 2}
+
+var imdbIDPattern = regexp.MustCompile(`^tt\d+$`)
+
+// Wikidata looks up original and localized titles by IMDb ID, something
+// Cinemeta doesn't expose.
+type Wikidata struct {
+	client *resty.Client
+}
+
+type Names struct {
+	Original     string
+	Alternatives []string
+}
+
+type sparqlResponse struct {
+	Results struct {
+		Bindings []sparqlBinding `json:"bindings"`
+	} `json:"results"`
+}
+
+type sparqlBinding struct {
+	Kind struct {
+		Value string `json:"value"`
+	} `json:"kind"`
+	Name struct {
+		Value string `json:"value"`
+	} `json:"name"`
+	Lang struct {
+		Value string `json:"value"`
+	} `json:"lang"`
+}
+
+func New(userAgent string) *Wikidata {
+	return &Wikidata{
+		client: tracing.InstrumentResty(resty.New().
+			SetBaseURL("https://query.wikidata.org").
+			SetHeader("User-Agent", userAgent).
+			SetHeader("Accept", "application/sparql-results+json").
+			SetTimeout(5*time.Second), "wikidata"),
+	}
+}
+
+// GetNamesByIMDBID returns the original title and the distinct alternative
+// titles of the given IMDb ID, original title first. Labels and aliases are
+// only kept in English and the language of the original title, the others
+// rarely show up in release names.
+func (w *Wikidata) GetNamesByIMDBID(ctx context.Context, id string) (*Names, error) {
+	if !imdbIDPattern.MatchString(id) {
+		return nil, fmt.Errorf("invalid imdb id: %s", id)
+	}
+
+	result := &sparqlResponse{}
+	resp, err := w.client.R().
+		SetContext(ctx).
+		SetQueryParam("query", fmt.Sprintf(namesQuery, id)).
+		SetResult(result).
+		Get("/sparql")
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.IsError() {
+		return nil, fmt.Errorf("error response from wikidata: %s", resp.Status())
+	}
+
+	// SPARQL results come in no particular order, keep the same names each time
+	bindings := result.Results.Bindings
+	slices.SortFunc(bindings, func(a, b sparqlBinding) int {
+		return cmp.Or(
+			cmp.Compare(kindOrder[a.Kind.Value], kindOrder[b.Kind.Value]),
+			strings.Compare(a.Name.Value, b.Name.Value),
+		)
+	})
+
+	names := &Names{}
+	languages := map[string]int{"en": 1}
+	if len(bindings) > 0 && bindings[0].Kind.Value == "original" {
+		names.Original = strings.TrimSpace(bindings[0].Name.Value)
+		if language := baseLanguage(bindings[0].Lang.Value); language != "" {
+			languages[language] = 0
+		}
+	}
+
+	bindings = slices.DeleteFunc(bindings, func(b sparqlBinding) bool {
+		_, ok := languages[baseLanguage(b.Lang.Value)]
+		return !ok && b.Kind.Value != "original"
+	})
+	slices.SortStableFunc(bindings, func(a, b sparqlBinding) int {
+		return cmp.Or(
+			cmp.Compare(kindOrder[a.Kind.Value], kindOrder[b.Kind.Value]),
+			cmp.Compare(languages[baseLanguage(a.Lang.Value)], languages[baseLanguage(b.Lang.Value)]),
+		)
+	})
+
+	seen := map[string]bool{strings.ToLower(names.Original): true}
+	for _, binding := range bindings {
+		name := strings.TrimSpace(binding.Name.Value)
+		key := strings.ToLower(name)
+		if name == "" || seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		names.Alternatives = append(names.Alternatives, name)
+		if len(names.Alternatives) == maxNames {
+			break
+		}
+	}
+
+	return names, nil
+}
+
+// baseLanguage drops the region of a language tag, "en-gb" is English.
+func baseLanguage(tag string) string {
+	language, _, _ := strings.Cut(strings.ToLower(tag), "-")
+	return language
+}
@@ -8,7 +8,14 @@ echo "=================================="
 # Check if SSL is enabled (default: true)
 SSL_ENABLED=${SSL_ENABLED:-true}
 
-if [ "$SSL_ENABLED" = "true" ]; then
+if [ "$SSL_ENABLED" = "true" ] && [ -n "$ACME_DOMAINS" ]; then
+    echo "SSL Mode: ENABLED (ACME)"
+    echo "Certificates for $ACME_DOMAINS are obtained and renewed by StreamX"
+    echo "=================================="
+    echo "HTTP Config: http://localhost:7000"
+    echo "HTTPS Stremio: https://$(echo $ACME_DOMAINS | cut -d, -f1)"
+    echo "=================================="
+elif [ "$SSL_ENABLED" = "true" ]; then
     echo "SSL Mode: ENABLED"
     
     # Start cron daemon for certificate renewal